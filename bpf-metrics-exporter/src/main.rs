@@ -3,9 +3,11 @@
 
 use std::time::SystemTime;
 
+use anyhow::Context;
 use aya::{
     maps::{loaded_maps, MapType as AyaMapType},
     programs::{loaded_links, loaded_programs, ProgramType as AyaProgramType},
+    sys::{enable_stats, Stats},
 };
 use bpfman::types::{MapType, ProgramType};
 use chrono::{prelude::DateTime, Utc};
@@ -39,12 +41,26 @@ fn init_meter_provider(grpc_endpoint: &str) -> SdkMeterProvider {
 struct Cli {
     #[clap(long, default_value = "http://localhost:4317")]
     otel_grpc: String,
+    /// Enable kernel BPF run-time statistics (bpf_enable_stats) and export
+    /// per-program run time and run count. The kernel then accounts every
+    /// program run, which adds a small overhead to each invocation.
+    #[clap(long)]
+    enable_stats: bool,
 }
 
 #[tokio::main]
 async fn main() -> anyhow::Result<()> {
     let cli = Cli::parse();
 
+    // The kernel keeps collecting run-time stats for as long as the returned
+    // fd is open, so hold it until the exporter exits.
+    let _stats_fd = if cli.enable_stats {
+        Some(enable_stats(Stats::RunTime).context("failed to enable BPF run-time stats")?)
+    } else {
+        None
+    };
+    let stats_enabled = cli.enable_stats;
+
     // Initialize the MeterProvider with the OTLP exporter.
     let meter_provider = init_meter_provider(&cli.otel_grpc);
 
@@ -100,6 +116,18 @@ async fn main() -> anyhow::Result<()> {
         .with_unit(Unit::new("instructions"))
         .init();
 
+    let bpf_program_run_time = meter
+        .u64_observable_counter("bpf_program_run_time")
+        .with_description("Total time spent running the BPF program, requires --enable-stats")
+        .with_unit(Unit::new("nanoseconds"))
+        .init();
+
+    let bpf_program_run_count = meter
+        .u64_observable_counter("bpf_program_run_count")
+        .with_description("Number of times the BPF program has run, requires --enable-stats")
+        .with_unit(Unit::new("runs"))
+        .init();
+
     let bpf_map_key_size = meter
         .u64_observable_counter("bpf_map_key_size")
         .with_description("BPF map key size")
@@ -129,6 +157,8 @@ async fn main() -> anyhow::Result<()> {
                 bpf_program_size_translated_bytes.as_any(),
                 bpf_program_mem_bytes.as_any(),
                 bpf_program_verified_instructions.as_any(),
+                bpf_program_run_time.as_any(),
+                bpf_program_run_count.as_any(),
                 bpf_map_key_size.as_any(),
                 bpf_map_value_size.as_any(),
                 bpf_map_max_entries.as_any(),
@@ -152,6 +182,8 @@ async fn main() -> anyhow::Result<()> {
                     let translated_bytes = program.size_translated().unwrap_or(0);
                     let mem_bytes = program.memory_locked().unwrap_or(0);
                     let verified_instructions = program.verified_instruction_count().unwrap_or(0);
+                    let run_time_ns = program.run_time().as_nanos() as u64;
+                    let run_count = program.run_count();
 
                     let prog_info_labels = [
                         KeyValue::new("id", id.to_string()),
@@ -203,6 +235,14 @@ async fn main() -> anyhow::Result<()> {
                         verified_instructions.into(),
                         &prog_key_labels,
                     );
+
+                    // Without --enable-stats the kernel reports zero for
+                    // every program, so leave these series out entirely.
+                    if stats_enabled {
+                        observer.observe_u64(&bpf_program_run_time, run_time_ns, &prog_key_labels);
+
+                        observer.observe_u64(&bpf_program_run_count, run_count, &prog_key_labels);
+                    }
                 }
 
                 for link in loaded_links().flatten() {
@@ -0,0 +1,211 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package builder provides a fluent API for constructing bpfman LoadRequests,
+// so Go programs can load eBPF programs through bpfman without assembling the
+// protobuf messages by hand.
+//
+//	req, err := builder.NewTcLoad().
+//		FromImage("quay.io/bpfman-bytecode/go-tc-counter:latest").
+//		Name("stats").
+//		Iface("eth0").
+//		Direction(builder.Ingress).
+//		Priority(50).
+//		Build()
+package builder
+
+import (
+	"fmt"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/bpfman/bpfman/clients/gobpfman/validation"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProgramType is the kernel program type bpfman expects in
+// LoadRequest.ProgramType.
+type ProgramType uint32
+
+const (
+	Kprobe     ProgramType = validation.ProgramTypeKprobe
+	Tc         ProgramType = validation.ProgramTypeTc
+	Tracepoint ProgramType = validation.ProgramTypeTracepoint
+	Xdp        ProgramType = validation.ProgramTypeXdp
+	Tracing    ProgramType = validation.ProgramTypeTracing
+)
+
+func (p ProgramType) String() string {
+	switch p {
+	case Kprobe:
+		return "kprobe"
+	case Tc:
+		return "tc"
+	case Tracepoint:
+		return "tracepoint"
+	case Xdp:
+		return "xdp"
+	case Tracing:
+		return "tracing"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint32(p))
+	}
+}
+
+// ImagePullPolicy mirrors the pull policy values understood by bpfman for
+// bytecode images.
+type ImagePullPolicy int32
+
+const (
+	PullAlways ImagePullPolicy = iota
+	PullIfNotPresent
+	PullNever
+)
+
+// base holds the fields common to every program type. It is embedded in each
+// typed builder with B set to that builder, so the shared setters can return
+// the concrete type and keep the chain fluent.
+type base[B any] struct {
+	self B
+	req  *gobpfman.LoadRequest
+	err  error
+}
+
+func newBase[B any](self B, progType ProgramType, attach *gobpfman.AttachInfo) base[B] {
+	return base[B]{
+		self: self,
+		req: &gobpfman.LoadRequest{
+			ProgramType: uint32(progType),
+			Attach:      attach,
+		},
+	}
+}
+
+// setErr records the first error hit while building so it can be reported by
+// Build without breaking the chain.
+func (b *base[B]) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// FromImage sets the bytecode source to an OCI image.
+func (b *base[B]) FromImage(url string) B {
+	b.req.Bytecode = &gobpfman.BytecodeLocation{
+		Location: &gobpfman.BytecodeLocation_Image{
+			Image: &gobpfman.BytecodeImage{
+				Url:             url,
+				ImagePullPolicy: int32(PullIfNotPresent),
+			},
+		},
+	}
+	return b.self
+}
+
+// ImagePullPolicy sets the pull policy of an image bytecode source. FromImage
+// must be called first.
+func (b *base[B]) ImagePullPolicy(policy ImagePullPolicy) B {
+	image := b.req.GetBytecode().GetImage()
+	if image == nil {
+		b.setErr(fmt.Errorf("image pull policy requires an image bytecode source"))
+		return b.self
+	}
+	image.ImagePullPolicy = int32(policy)
+	return b.self
+}
+
+// ImageCredentials sets the registry credentials of an image bytecode source.
+// FromImage must be called first.
+func (b *base[B]) ImageCredentials(username, password string) B {
+	image := b.req.GetBytecode().GetImage()
+	if image == nil {
+		b.setErr(fmt.Errorf("image credentials require an image bytecode source"))
+		return b.self
+	}
+	image.Username = &username
+	image.Password = &password
+	return b.self
+}
+
+// FromFile sets the bytecode source to a file on the node running bpfman.
+func (b *base[B]) FromFile(path string) B {
+	b.req.Bytecode = &gobpfman.BytecodeLocation{
+		Location: &gobpfman.BytecodeLocation_File{File: path},
+	}
+	return b.self
+}
+
+// FromBytecode sets an already constructed bytecode location. The location is
+// copied, so later ImagePullPolicy or ImageCredentials calls leave the
+// caller's value untouched.
+func (b *base[B]) FromBytecode(location *gobpfman.BytecodeLocation) B {
+	b.req.Bytecode = proto.Clone(location).(*gobpfman.BytecodeLocation)
+	return b.self
+}
+
+// Name sets the name of the program's function within the bytecode.
+func (b *base[B]) Name(name string) B {
+	b.req.Name = name
+	return b.self
+}
+
+// Metadata adds a key/value pair to the program's metadata.
+func (b *base[B]) Metadata(key, value string) B {
+	if b.req.Metadata == nil {
+		b.req.Metadata = map[string]string{}
+	}
+	b.req.Metadata[key] = value
+	return b.self
+}
+
+// GlobalData sets the initial value of a global variable in the bytecode. The
+// value is copied, so the caller may reuse its buffer.
+func (b *base[B]) GlobalData(name string, value []byte) B {
+	if b.req.GlobalData == nil {
+		b.req.GlobalData = map[string][]byte{}
+	}
+	b.req.GlobalData[name] = append([]byte(nil), value...)
+	return b.self
+}
+
+// UUID sets the optional UUID of the program.
+func (b *base[B]) UUID(uuid string) B {
+	b.req.Uuid = &uuid
+	return b.self
+}
+
+// MapOwner sets the kernel ID of an already loaded program whose maps this
+// program shares. An ID of zero leaves the program as its own map owner.
+func (b *base[B]) MapOwner(id uint32) B {
+	if id == 0 {
+		b.req.MapOwnerId = nil
+		return b.self
+	}
+	b.req.MapOwnerId = &id
+	return b.self
+}
+
+// Build validates the accumulated fields with validation.ValidateLoadRequest
+// and returns a copy of the resulting LoadRequest, so the builder can be
+// reused for further requests.
+func (b *base[B]) Build() (*gobpfman.LoadRequest, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if err := validation.ValidateLoadRequest(b.req); err != nil {
+		return nil, err
+	}
+	return proto.Clone(b.req).(*gobpfman.LoadRequest), nil
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGolden builds a LoadRequest for each program type, shaped like the
+// bpfman-operator sample CRDs, and compares it with testdata/<name>.golden.
+// Run with -update to regenerate the golden files after an intended change.
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (*gobpfman.LoadRequest, error)
+	}{
+		{
+			name: "xdp",
+			build: NewXdpLoad().
+				FromImage("quay.io/bpfman-bytecode/go-xdp-counter:latest").
+				Name("xdp_stats").
+				Iface("eth0").
+				Priority(55).
+				ProceedOn(XdpPass, XdpDispatcherReturn).
+				Metadata("bpfman.io/ProgramName", "go-xdp-counter-example").
+				Build,
+		},
+		{
+			name: "xdp_file",
+			build: NewXdpLoad().
+				FromFile("/run/bpfman/examples/xdp_pass.bpf.o").
+				Name("pass").
+				Iface("eth0").
+				Netns("/var/run/netns/test").
+				Build,
+		},
+		{
+			name: "tc",
+			build: NewTcLoad().
+				FromImage("quay.io/bpfman-bytecode/go-tc-counter:latest").
+				ImagePullPolicy(PullAlways).
+				Name("stats").
+				Iface("eth0").
+				Direction(Ingress).
+				Priority(55).
+				ProceedOn(TcPipe, TcDispatcherReturn).
+				GlobalData("GLOBAL_u8", []byte{0x01}).
+				GlobalData("GLOBAL_u32", []byte{0x0d, 0x0c, 0x0b, 0x0a}).
+				Build,
+		},
+		{
+			name: "tcx",
+			build: NewTcxLoad().
+				FromImage("quay.io/bpfman-bytecode/go-tc-counter:latest").
+				Name("tcx_stats").
+				Iface("eth0").
+				Direction(Egress).
+				Priority(500).
+				Build,
+		},
+		{
+			name: "tracepoint",
+			build: NewTracepointLoad().
+				FromImage("quay.io/bpfman-bytecode/go-tracepoint-counter:latest").
+				Name("tracepoint_kill_recorder").
+				Tracepoint("syscalls/sys_enter_kill").
+				MapOwner(42).
+				Build,
+		},
+		{
+			name: "kprobe",
+			build: NewKprobeLoad().
+				FromImage("quay.io/bpfman-bytecode/go-kprobe-counter:latest").
+				Name("kprobe_counter").
+				FnName("try_to_wake_up").
+				Build,
+		},
+		{
+			name: "kretprobe",
+			build: NewKprobeLoad().
+				FromImage("quay.io/bpfman-bytecode/go-kprobe-counter:latest").
+				Name("kprobe_counter").
+				FnName("try_to_wake_up").
+				Retprobe().
+				Build,
+		},
+		{
+			name: "uprobe",
+			build: NewUprobeLoad().
+				FromImage("quay.io/bpfman-bytecode/go-uprobe-counter:latest").
+				ImageCredentials("user", "secret").
+				Name("uprobe_counter").
+				Target("libc").
+				FnName("malloc").
+				ContainerPid(1234).
+				Build,
+		},
+		{
+			name: "uretprobe",
+			build: NewUprobeLoad().
+				FromImage("quay.io/bpfman-bytecode/go-uretprobe-counter:latest").
+				Name("uretprobe_counter").
+				Target("libc").
+				FnName("malloc").
+				Offset(16).
+				Retprobe().
+				Pid(1).
+				Build,
+		},
+		{
+			name: "fentry",
+			build: NewFentryLoad().
+				FromImage("quay.io/bpfman-bytecode/fentry:latest").
+				Name("test_fentry").
+				FnName("do_unlinkat").
+				UUID("b8a4c87d-1b0e-4d6b-9b8c-2f2cbd7e1f3a").
+				Build,
+		},
+		{
+			name: "fexit",
+			build: NewFexitLoad().
+				FromImage("quay.io/bpfman-bytecode/fexit:latest").
+				Name("test_fexit").
+				FnName("do_unlinkat").
+				Build,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := tt.build()
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			path := filepath.Join("testdata", tt.name+".golden")
+			got, err := prototext.MarshalOptions{Multiline: true}.Marshal(req)
+			if err != nil {
+				t.Fatalf("failed to marshal request: %v", err)
+			}
+
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("failed to write %s: %v", path, err)
+				}
+				return
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read %s, run with -update to create it: %v", path, err)
+			}
+			// prototext output is deliberately unstable, so compare messages
+			// rather than bytes.
+			want := &gobpfman.LoadRequest{}
+			if err := prototext.Unmarshal(data, want); err != nil {
+				t.Fatalf("failed to parse %s: %v", path, err)
+			}
+			if !proto.Equal(req, want) {
+				t.Errorf("request does not match %s\ngot:\n%s\nwant:\n%s", path, got, data)
+			}
+		})
+	}
+}
+
+func TestFromBytecodeCopiesLocation(t *testing.T) {
+	loc := &gobpfman.BytecodeLocation{
+		Location: &gobpfman.BytecodeLocation_Image{Image: &gobpfman.BytecodeImage{
+			Url:             "quay.io/bpfman-bytecode/go-xdp-counter:latest",
+			ImagePullPolicy: int32(PullIfNotPresent),
+		}},
+	}
+	req, err := NewXdpLoad().
+		FromBytecode(loc).
+		ImagePullPolicy(PullNever).
+		ImageCredentials("user", "secret").
+		Name("xdp_stats").
+		Iface("eth0").
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if got := loc.GetImage().GetImagePullPolicy(); got != int32(PullIfNotPresent) {
+		t.Errorf("caller's image pull policy changed to %d", got)
+	}
+	if loc.GetImage().Username != nil {
+		t.Errorf("caller's image credentials were set")
+	}
+	if got := req.GetBytecode().GetImage().GetImagePullPolicy(); got != int32(PullNever) {
+		t.Errorf("request image pull policy is %d, expected %d", got, PullNever)
+	}
+}
+
+func TestFromBytecodeNil(t *testing.T) {
+	if _, err := NewXdpLoad().FromBytecode(nil).Name("xdp_stats").Iface("eth0").Build(); err == nil {
+		t.Errorf("Build succeeded without a bytecode source")
+	}
+}
+
+func TestGlobalDataCopiesValue(t *testing.T) {
+	value := []byte{0x01}
+	b := NewXdpLoad().
+		FromFile("/tmp/xdp.o").
+		Name("xdp_stats").
+		Iface("eth0").
+		GlobalData("sampling", value)
+	value[0] = 0x02
+
+	req, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if got := req.GetGlobalData()["sampling"]; len(got) != 1 || got[0] != 0x01 {
+		t.Errorf("global data is %v, expected [1]", got)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+
+// KprobeLoadBuilder builds a LoadRequest for a kprobe or kretprobe program.
+type KprobeLoadBuilder struct {
+	base[*KprobeLoadBuilder]
+	attach *gobpfman.KprobeAttachInfo
+}
+
+// NewKprobeLoad returns a builder for loading a kprobe program.
+func NewKprobeLoad() *KprobeLoadBuilder {
+	b := &KprobeLoadBuilder{attach: &gobpfman.KprobeAttachInfo{}}
+	b.base = newBase(b, Kprobe, &gobpfman.AttachInfo{
+		Info: &gobpfman.AttachInfo_KprobeAttachInfo{KprobeAttachInfo: b.attach},
+	})
+	return b
+}
+
+// FnName sets the kernel function the probe is attached to.
+func (b *KprobeLoadBuilder) FnName(fnName string) *KprobeLoadBuilder {
+	b.attach.FnName = fnName
+	return b
+}
+
+// Offset sets the offset within the function at which the probe fires.
+func (b *KprobeLoadBuilder) Offset(offset uint64) *KprobeLoadBuilder {
+	b.attach.Offset = offset
+	return b
+}
+
+// Retprobe attaches the program as a kretprobe.
+func (b *KprobeLoadBuilder) Retprobe() *KprobeLoadBuilder {
+	b.attach.Retprobe = true
+	return b
+}
+
+// ContainerPid sets the PID of a process in the container to attach in.
+func (b *KprobeLoadBuilder) ContainerPid(pid int32) *KprobeLoadBuilder {
+	b.attach.ContainerPid = &pid
+	return b
+}
+
+// UprobeLoadBuilder builds a LoadRequest for a uprobe or uretprobe program.
+type UprobeLoadBuilder struct {
+	base[*UprobeLoadBuilder]
+	attach *gobpfman.UprobeAttachInfo
+}
+
+// NewUprobeLoad returns a builder for loading a uprobe program.
+func NewUprobeLoad() *UprobeLoadBuilder {
+	b := &UprobeLoadBuilder{attach: &gobpfman.UprobeAttachInfo{}}
+	b.base = newBase(b, Kprobe, &gobpfman.AttachInfo{
+		Info: &gobpfman.AttachInfo_UprobeAttachInfo{UprobeAttachInfo: b.attach},
+	})
+	return b
+}
+
+// Target sets the library name or absolute path of the binary to probe.
+func (b *UprobeLoadBuilder) Target(target string) *UprobeLoadBuilder {
+	b.attach.Target = target
+	return b
+}
+
+// FnName sets the function within the target the probe is attached to.
+func (b *UprobeLoadBuilder) FnName(fnName string) *UprobeLoadBuilder {
+	b.attach.FnName = &fnName
+	return b
+}
+
+// Offset sets the offset, relative to FnName if set, at which the probe fires.
+func (b *UprobeLoadBuilder) Offset(offset uint64) *UprobeLoadBuilder {
+	b.attach.Offset = offset
+	return b
+}
+
+// Retprobe attaches the program as a uretprobe.
+func (b *UprobeLoadBuilder) Retprobe() *UprobeLoadBuilder {
+	b.attach.Retprobe = true
+	return b
+}
+
+// Pid restricts the probe to a single process.
+func (b *UprobeLoadBuilder) Pid(pid int32) *UprobeLoadBuilder {
+	b.attach.Pid = &pid
+	return b
+}
+
+// ContainerPid sets the PID of a process in the container to attach in.
+func (b *UprobeLoadBuilder) ContainerPid(pid int32) *UprobeLoadBuilder {
+	b.attach.ContainerPid = &pid
+	return b
+}
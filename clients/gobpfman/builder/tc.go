@@ -0,0 +1,140 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/bpfman/bpfman/clients/gobpfman/validation"
+)
+
+// Direction is the traffic direction a TC or TCX program is attached to.
+type Direction string
+
+const (
+	Ingress Direction = "ingress"
+	Egress  Direction = "egress"
+)
+
+// TcProceedOn is a TC program return code on which the dispatcher moves on to
+// the next program in the chain.
+type TcProceedOn int32
+
+const (
+	TcUnspec           TcProceedOn = validation.TcUnspec
+	TcOk               TcProceedOn = validation.TcOk
+	TcReclassify       TcProceedOn = validation.TcReclassify
+	TcShot             TcProceedOn = validation.TcShot
+	TcPipe             TcProceedOn = validation.TcPipe
+	TcStolen           TcProceedOn = validation.TcStolen
+	TcQueued           TcProceedOn = validation.TcQueued
+	TcRepeat           TcProceedOn = validation.TcRepeat
+	TcRedirect         TcProceedOn = validation.TcRedirect
+	TcTrap             TcProceedOn = validation.TcTrap
+	TcDispatcherReturn TcProceedOn = validation.TcDispatcherReturn
+)
+
+// TcLoadBuilder builds a LoadRequest for a TC program.
+type TcLoadBuilder struct {
+	base[*TcLoadBuilder]
+	attach *gobpfman.TCAttachInfo
+}
+
+// NewTcLoad returns a builder for loading a TC program. The direction
+// defaults to ingress.
+func NewTcLoad() *TcLoadBuilder {
+	b := &TcLoadBuilder{attach: &gobpfman.TCAttachInfo{Direction: string(Ingress)}}
+	b.base = newBase(b, Tc, &gobpfman.AttachInfo{
+		Info: &gobpfman.AttachInfo_TcAttachInfo{TcAttachInfo: b.attach},
+	})
+	return b
+}
+
+// Iface sets the interface the program is attached to.
+func (b *TcLoadBuilder) Iface(iface string) *TcLoadBuilder {
+	b.attach.Iface = iface
+	return b
+}
+
+// Direction sets whether the program runs on ingress or egress traffic.
+func (b *TcLoadBuilder) Direction(direction Direction) *TcLoadBuilder {
+	b.attach.Direction = string(direction)
+	return b
+}
+
+// Priority sets the program's position in the dispatcher chain. Lower values
+// run first.
+func (b *TcLoadBuilder) Priority(priority int32) *TcLoadBuilder {
+	b.attach.Priority = priority
+	return b
+}
+
+// ProceedOn sets the return codes on which the next program in the chain is
+// run. If never called, bpfman applies its defaults.
+func (b *TcLoadBuilder) ProceedOn(codes ...TcProceedOn) *TcLoadBuilder {
+	b.attach.ProceedOn = b.attach.ProceedOn[:0]
+	for _, c := range codes {
+		b.attach.ProceedOn = append(b.attach.ProceedOn, int32(c))
+	}
+	return b
+}
+
+// Netns sets the network namespace containing the interface.
+func (b *TcLoadBuilder) Netns(netns string) *TcLoadBuilder {
+	b.attach.Netns = &netns
+	return b
+}
+
+// TcxLoadBuilder builds a LoadRequest for a TCX program.
+type TcxLoadBuilder struct {
+	base[*TcxLoadBuilder]
+	attach *gobpfman.TCXAttachInfo
+}
+
+// NewTcxLoad returns a builder for loading a TCX program. The direction
+// defaults to ingress.
+func NewTcxLoad() *TcxLoadBuilder {
+	b := &TcxLoadBuilder{attach: &gobpfman.TCXAttachInfo{Direction: string(Ingress)}}
+	b.base = newBase(b, Tc, &gobpfman.AttachInfo{
+		Info: &gobpfman.AttachInfo_TcxAttachInfo{TcxAttachInfo: b.attach},
+	})
+	return b
+}
+
+// Iface sets the interface the program is attached to.
+func (b *TcxLoadBuilder) Iface(iface string) *TcxLoadBuilder {
+	b.attach.Iface = iface
+	return b
+}
+
+// Direction sets whether the program runs on ingress or egress traffic.
+func (b *TcxLoadBuilder) Direction(direction Direction) *TcxLoadBuilder {
+	b.attach.Direction = string(direction)
+	return b
+}
+
+// Priority sets the program's position among the TCX programs on the
+// interface. Lower values run first.
+func (b *TcxLoadBuilder) Priority(priority int32) *TcxLoadBuilder {
+	b.attach.Priority = priority
+	return b
+}
+
+// Netns sets the network namespace containing the interface.
+func (b *TcxLoadBuilder) Netns(netns string) *TcxLoadBuilder {
+	b.attach.Netns = &netns
+	return b
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+
+// TracepointLoadBuilder builds a LoadRequest for a tracepoint program.
+type TracepointLoadBuilder struct {
+	base[*TracepointLoadBuilder]
+	attach *gobpfman.TracepointAttachInfo
+}
+
+// NewTracepointLoad returns a builder for loading a tracepoint program.
+func NewTracepointLoad() *TracepointLoadBuilder {
+	b := &TracepointLoadBuilder{attach: &gobpfman.TracepointAttachInfo{}}
+	b.base = newBase(b, Tracepoint, &gobpfman.AttachInfo{
+		Info: &gobpfman.AttachInfo_TracepointAttachInfo{TracepointAttachInfo: b.attach},
+	})
+	return b
+}
+
+// Tracepoint sets the tracepoint to attach to, in "category/name" form, for
+// example "syscalls/sys_enter_kill".
+func (b *TracepointLoadBuilder) Tracepoint(tracepoint string) *TracepointLoadBuilder {
+	b.attach.Tracepoint = tracepoint
+	return b
+}
+
+// FentryLoadBuilder builds a LoadRequest for an fentry program.
+type FentryLoadBuilder struct {
+	base[*FentryLoadBuilder]
+	attach *gobpfman.FentryAttachInfo
+}
+
+// NewFentryLoad returns a builder for loading an fentry program.
+func NewFentryLoad() *FentryLoadBuilder {
+	b := &FentryLoadBuilder{attach: &gobpfman.FentryAttachInfo{}}
+	b.base = newBase(b, Tracing, &gobpfman.AttachInfo{
+		Info: &gobpfman.AttachInfo_FentryAttachInfo{FentryAttachInfo: b.attach},
+	})
+	return b
+}
+
+// FnName sets the kernel function the program is attached to.
+func (b *FentryLoadBuilder) FnName(fnName string) *FentryLoadBuilder {
+	b.attach.FnName = fnName
+	return b
+}
+
+// FexitLoadBuilder builds a LoadRequest for an fexit program.
+type FexitLoadBuilder struct {
+	base[*FexitLoadBuilder]
+	attach *gobpfman.FexitAttachInfo
+}
+
+// NewFexitLoad returns a builder for loading an fexit program.
+func NewFexitLoad() *FexitLoadBuilder {
+	b := &FexitLoadBuilder{attach: &gobpfman.FexitAttachInfo{}}
+	b.base = newBase(b, Tracing, &gobpfman.AttachInfo{
+		Info: &gobpfman.AttachInfo_FexitAttachInfo{FexitAttachInfo: b.attach},
+	})
+	return b
+}
+
+// FnName sets the kernel function the program is attached to.
+func (b *FexitLoadBuilder) FnName(fnName string) *FexitLoadBuilder {
+	b.attach.FnName = fnName
+	return b
+}
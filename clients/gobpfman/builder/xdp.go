@@ -0,0 +1,79 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/bpfman/bpfman/clients/gobpfman/validation"
+)
+
+// XdpProceedOn is an XDP program return code on which the dispatcher moves on
+// to the next program in the chain.
+type XdpProceedOn int32
+
+const (
+	XdpAborted          XdpProceedOn = validation.XdpAborted
+	XdpDrop             XdpProceedOn = validation.XdpDrop
+	XdpPass             XdpProceedOn = validation.XdpPass
+	XdpTx               XdpProceedOn = validation.XdpTx
+	XdpRedirect         XdpProceedOn = validation.XdpRedirect
+	XdpDispatcherReturn XdpProceedOn = validation.XdpDispatcherReturn
+)
+
+// XdpLoadBuilder builds a LoadRequest for an XDP program.
+type XdpLoadBuilder struct {
+	base[*XdpLoadBuilder]
+	attach *gobpfman.XDPAttachInfo
+}
+
+// NewXdpLoad returns a builder for loading an XDP program.
+func NewXdpLoad() *XdpLoadBuilder {
+	b := &XdpLoadBuilder{attach: &gobpfman.XDPAttachInfo{}}
+	b.base = newBase(b, Xdp, &gobpfman.AttachInfo{
+		Info: &gobpfman.AttachInfo_XdpAttachInfo{XdpAttachInfo: b.attach},
+	})
+	return b
+}
+
+// Iface sets the interface the program is attached to.
+func (b *XdpLoadBuilder) Iface(iface string) *XdpLoadBuilder {
+	b.attach.Iface = iface
+	return b
+}
+
+// Priority sets the program's position in the dispatcher chain. Lower values
+// run first.
+func (b *XdpLoadBuilder) Priority(priority int32) *XdpLoadBuilder {
+	b.attach.Priority = priority
+	return b
+}
+
+// ProceedOn sets the return codes on which the next program in the chain is
+// run. If never called, bpfman applies its defaults.
+func (b *XdpLoadBuilder) ProceedOn(codes ...XdpProceedOn) *XdpLoadBuilder {
+	b.attach.ProceedOn = b.attach.ProceedOn[:0]
+	for _, c := range codes {
+		b.attach.ProceedOn = append(b.attach.ProceedOn, int32(c))
+	}
+	return b
+}
+
+// Netns sets the network namespace containing the interface.
+func (b *XdpLoadBuilder) Netns(netns string) *XdpLoadBuilder {
+	b.attach.Netns = &netns
+	return b
+}
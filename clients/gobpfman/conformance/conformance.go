@@ -0,0 +1,445 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance exercises the bpfman gRPC contract against any server
+// implementing it, so alternative daemons can check that they behave like
+// bpfman for the clients built on gobpfman.
+//
+// The suite is run from a regular Go test in the implementation's own
+// repository:
+//
+//	func TestConformance(t *testing.T) {
+//		conn := ... // connect to the daemon under test
+//		conformance.Run(t, gobpfman.NewBpfmanClient(conn), conformance.Config{
+//			Bytecode: &gobpfman.BytecodeLocation{
+//				Location: &gobpfman.BytecodeLocation_Image{Image: &gobpfman.BytecodeImage{
+//					Url: "quay.io/bpfman-bytecode/go-tracepoint-counter:latest",
+//				}},
+//			},
+//			TracepointProgram: "tracepoint_kill_recorder",
+//			Tracepoint:        "syscalls/sys_enter_kill",
+//		})
+//	}
+//
+// Every program the suite loads is tagged with a per-run metadata value and
+// unloaded when its subtest finishes.
+package conformance
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+const (
+	// RunMetadataKey is the metadata key carrying the per-run ID on every
+	// program loaded by the suite.
+	RunMetadataKey = "bpfman.io/conformance-run"
+
+	defaultTimeout = 30 * time.Second
+
+	// dispatcherSlots is the number of programs bpfman attaches to a single
+	// XDP or TC dispatcher.
+	dispatcherSlots = 10
+)
+
+// Config describes the bytecode and attach points the suite uses.
+type Config struct {
+	// Bytecode is the object file or image holding the programs below.
+	// Required.
+	Bytecode *gobpfman.BytecodeLocation
+
+	// TracepointProgram is the name of a tracepoint program in Bytecode,
+	// attached to Tracepoint. Required.
+	TracepointProgram string
+	Tracepoint        string
+
+	// XdpProgram is the name of an XDP program in Bytecode, attached to
+	// Iface. If empty, the dispatcher ordering and slot limit checks are
+	// skipped.
+	XdpProgram string
+	Iface      string
+
+	// Timeout bounds each RPC. Defaults to 30 seconds.
+	Timeout time.Duration
+}
+
+type suite struct {
+	c     gobpfman.BpfmanClient
+	cfg   Config
+	runID string
+}
+
+// Run executes the conformance checks as subtests of t.
+func Run(t *testing.T, c gobpfman.BpfmanClient, cfg Config) {
+	if cfg.Bytecode == nil || len(cfg.TracepointProgram) == 0 || len(cfg.Tracepoint) == 0 {
+		t.Fatalf("conformance: Bytecode, TracepointProgram and Tracepoint are required")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	s := &suite{
+		c:     c,
+		cfg:   cfg,
+		runID: strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+
+	t.Run("LoadGetUnload", s.testLoadGetUnload)
+	t.Run("ListFilters", s.testListFilters)
+	t.Run("MapSharing", s.testMapSharing)
+	t.Run("InvalidMapOwner", s.testInvalidMapOwner)
+	t.Run("UnloadUnknown", s.testUnloadUnknown)
+	t.Run("DispatcherOrdering", s.testDispatcherOrdering)
+	t.Run("DispatcherTieBreak", s.testDispatcherTieBreak)
+	t.Run("DispatcherLimit", s.testDispatcherLimit)
+}
+
+func (s *suite) testLoadGetUnload(t *testing.T) {
+	res := s.load(t, must(t, s.tracepoint().Metadata("conformance-test", "load").Build))
+	id := res.GetKernelInfo().GetId()
+
+	if id == 0 {
+		t.Fatalf("Load returned no kernel ID")
+	}
+	if got := res.GetInfo().GetName(); got != s.cfg.TracepointProgram {
+		t.Errorf("Load returned name %q, expected %q", got, s.cfg.TracepointProgram)
+	}
+	if got := res.GetInfo().GetMetadata()["conformance-test"]; got != "load" {
+		t.Errorf("Load returned metadata %v, expected conformance-test=load", res.GetInfo().GetMetadata())
+	}
+	if len(res.GetInfo().GetMapPinPath()) == 0 {
+		t.Errorf("Load returned an empty map pin path")
+	}
+	if got := res.GetInfo().GetAttach().GetTracepointAttachInfo().GetTracepoint(); got != s.cfg.Tracepoint {
+		t.Errorf("Load returned tracepoint %q, expected %q", got, s.cfg.Tracepoint)
+	}
+
+	ctx, cancel := s.ctx()
+	defer cancel()
+	get, err := s.c.Get(ctx, &gobpfman.GetRequest{Id: id})
+	if err != nil {
+		t.Fatalf("Get(%d) failed: %v", id, err)
+	}
+	if get.GetInfo() == nil {
+		t.Fatalf("Get(%d) returned no program info for a bpfman program", id)
+	}
+	if get.GetKernelInfo().GetId() != id {
+		t.Errorf("Get(%d) returned kernel ID %d", id, get.GetKernelInfo().GetId())
+	}
+	if get.GetInfo().GetMapPinPath() != res.GetInfo().GetMapPinPath() {
+		t.Errorf("Get(%d) returned map pin path %q, Load returned %q",
+			id, get.GetInfo().GetMapPinPath(), res.GetInfo().GetMapPinPath())
+	}
+
+	s.unload(t, id)
+	// The kernel may reuse the ID, but not for a bpfman program.
+	if get, err := s.c.Get(ctx, &gobpfman.GetRequest{Id: id}); err == nil && get.GetInfo() != nil {
+		t.Errorf("Get(%d) still returns program info after Unload", id)
+	}
+}
+
+func (s *suite) testListFilters(t *testing.T) {
+	a := s.load(t, must(t, s.tracepoint().Metadata("conformance-test", "list-a").Build))
+	b := s.load(t, must(t, s.tracepoint().Metadata("conformance-test", "list-b").Build))
+	idA, idB := a.GetKernelInfo().GetId(), b.GetKernelInfo().GetId()
+
+	ids := s.list(t, &gobpfman.ListRequest{
+		MatchMetadata: map[string]string{RunMetadataKey: s.runID, "conformance-test": "list-a"},
+	})
+	if !slices.Equal(ids, []uint32{idA}) {
+		t.Errorf("List by metadata returned %v, expected [%d]", ids, idA)
+	}
+
+	ids = s.list(t, &gobpfman.ListRequest{
+		MatchMetadata: map[string]string{RunMetadataKey: s.runID},
+	})
+	if !slices.Contains(ids, idA) || !slices.Contains(ids, idB) {
+		t.Errorf("List by run metadata returned %v, expected %d and %d", ids, idA, idB)
+	}
+
+	programType := uint32(builder.Tracepoint)
+	ids = s.list(t, &gobpfman.ListRequest{
+		ProgramType:   &programType,
+		MatchMetadata: map[string]string{RunMetadataKey: s.runID},
+	})
+	if !slices.Contains(ids, idA) || !slices.Contains(ids, idB) {
+		t.Errorf("List by program type %d returned %v, expected %d and %d", programType, ids, idA, idB)
+	}
+
+	otherType := uint32(builder.Kprobe)
+	ids = s.list(t, &gobpfman.ListRequest{
+		ProgramType:   &otherType,
+		MatchMetadata: map[string]string{RunMetadataKey: s.runID},
+	})
+	if len(ids) != 0 {
+		t.Errorf("List by program type %d returned tracepoint programs %v", otherType, ids)
+	}
+
+	bpfmanOnly := true
+	ctx, cancel := s.ctx()
+	defer cancel()
+	res, err := s.c.List(ctx, &gobpfman.ListRequest{BpfmanProgramsOnly: &bpfmanOnly})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	for _, r := range res.GetResults() {
+		if r.GetInfo() == nil {
+			t.Errorf("List with bpfman_programs_only returned program %d without program info", r.GetKernelInfo().GetId())
+		}
+	}
+}
+
+func (s *suite) testMapSharing(t *testing.T) {
+	owner := s.load(t, must(t, s.tracepoint().Build))
+	ownerID := owner.GetKernelInfo().GetId()
+	user := s.load(t, must(t, s.tracepoint().MapOwner(ownerID).Build))
+	userID := user.GetKernelInfo().GetId()
+
+	if user.GetInfo().GetMapOwnerId() != ownerID {
+		t.Errorf("Load returned map owner %d, expected %d", user.GetInfo().GetMapOwnerId(), ownerID)
+	}
+	if user.GetInfo().GetMapPinPath() != owner.GetInfo().GetMapPinPath() {
+		t.Errorf("map user pins maps at %q, owner at %q", user.GetInfo().GetMapPinPath(), owner.GetInfo().GetMapPinPath())
+	}
+	usedBy := user.GetInfo().GetMapUsedBy()
+	for _, id := range []uint32{ownerID, userID} {
+		if !slices.Contains(usedBy, strconv.FormatUint(uint64(id), 10)) {
+			t.Errorf("map_used_by %v does not include %d", usedBy, id)
+		}
+	}
+
+	// Maps outlive their owner while other programs still use them.
+	s.unload(t, ownerID)
+	ctx, cancel := s.ctx()
+	defer cancel()
+	get, err := s.c.Get(ctx, &gobpfman.GetRequest{Id: userID})
+	if err != nil {
+		t.Fatalf("Get(%d) failed after unloading its map owner: %v", userID, err)
+	}
+	if get.GetInfo().GetMapPinPath() != owner.GetInfo().GetMapPinPath() {
+		t.Errorf("map pin path changed to %q after unloading the map owner", get.GetInfo().GetMapPinPath())
+	}
+	if usedBy := get.GetInfo().GetMapUsedBy(); slices.Contains(usedBy, strconv.FormatUint(uint64(ownerID), 10)) {
+		t.Errorf("map_used_by %v still includes unloaded owner %d", usedBy, ownerID)
+	}
+}
+
+func (s *suite) testInvalidMapOwner(t *testing.T) {
+	// Load and unload a program to find an ID that is not a map owner.
+	res := s.load(t, must(t, s.tracepoint().Build))
+	staleID := res.GetKernelInfo().GetId()
+	s.unload(t, staleID)
+
+	req := must(t, s.tracepoint().MapOwner(staleID).Build)
+	ctx, cancel := s.ctx()
+	defer cancel()
+	res, err := s.c.Load(ctx, req)
+	if err == nil {
+		s.cleanup(t, res.GetKernelInfo().GetId())
+		t.Fatalf("Load with unknown map owner %d succeeded", staleID)
+	}
+}
+
+func (s *suite) testUnloadUnknown(t *testing.T) {
+	res := s.load(t, must(t, s.tracepoint().Build))
+	id := res.GetKernelInfo().GetId()
+	s.unload(t, id)
+
+	ctx, cancel := s.ctx()
+	defer cancel()
+	if _, err := s.c.Unload(ctx, &gobpfman.UnloadRequest{Id: id}); err == nil {
+		t.Errorf("second Unload(%d) succeeded", id)
+	}
+}
+
+func (s *suite) testDispatcherOrdering(t *testing.T) {
+	if len(s.cfg.XdpProgram) == 0 {
+		t.Skip("no XDP program configured")
+	}
+	low := s.load(t, s.xdp(t, 50)).GetKernelInfo().GetId()
+	high := s.load(t, s.xdp(t, 10)).GetKernelInfo().GetId()
+	if s.position(t, high) >= s.position(t, low) {
+		t.Errorf("priority 10 program is at position %d, priority 50 program at %d",
+			s.position(t, high), s.position(t, low))
+	}
+}
+
+// testDispatcherTieBreak checks that, at equal priority, bpfman places the
+// program being loaded ahead of those already attached.
+func (s *suite) testDispatcherTieBreak(t *testing.T) {
+	if len(s.cfg.XdpProgram) == 0 {
+		t.Skip("no XDP program configured")
+	}
+	first := s.load(t, s.xdp(t, 50)).GetKernelInfo().GetId()
+	second := s.load(t, s.xdp(t, 50)).GetKernelInfo().GetId()
+	if s.position(t, second) >= s.position(t, first) {
+		t.Errorf("second program at priority 50 is at position %d, first at %d",
+			s.position(t, second), s.position(t, first))
+	}
+}
+
+// testDispatcherLimit checks that a single XDP dispatcher refuses programs
+// once all of its slots are taken.
+func (s *suite) testDispatcherLimit(t *testing.T) {
+	if len(s.cfg.XdpProgram) == 0 {
+		t.Skip("no XDP program configured")
+	}
+	for i := 0; i <= dispatcherSlots; i++ {
+		ctx, cancel := s.ctx()
+		res, err := s.c.Load(ctx, s.xdp(t, 50))
+		cancel()
+		if err != nil {
+			// Other programs may already be attached to the interface, so
+			// the dispatcher can fill up before this run adds all of its
+			// programs.
+			if n := s.attachedXdp(t); n != dispatcherSlots {
+				t.Errorf("Load failed with %d XDP programs on %s, expected %d: %v",
+					n, s.cfg.Iface, dispatcherSlots, err)
+			}
+			return
+		}
+		s.cleanup(t, res.GetKernelInfo().GetId())
+	}
+	t.Errorf("loaded %d XDP programs on %s, expected at most %d",
+		dispatcherSlots+1, s.cfg.Iface, dispatcherSlots)
+}
+
+// xdp returns a request for the configured XDP program at priority, tagged
+// with the run ID.
+func (s *suite) xdp(t *testing.T, priority int32) *gobpfman.LoadRequest {
+	t.Helper()
+	return must(t, builder.NewXdpLoad().
+		FromBytecode(s.cfg.Bytecode).
+		Name(s.cfg.XdpProgram).
+		Iface(s.cfg.Iface).
+		Priority(priority).
+		Metadata(RunMetadataKey, s.runID).
+		Build)
+}
+
+// position returns the dispatcher position of the XDP program id.
+func (s *suite) position(t *testing.T, id uint32) int32 {
+	t.Helper()
+	ctx, cancel := s.ctx()
+	defer cancel()
+	get, err := s.c.Get(ctx, &gobpfman.GetRequest{Id: id})
+	if err != nil {
+		t.Fatalf("Get(%d) failed: %v", id, err)
+	}
+	return get.GetInfo().GetAttach().GetXdpAttachInfo().GetPosition()
+}
+
+// attachedXdp counts the XDP programs attached to the configured interface,
+// whoever loaded them.
+func (s *suite) attachedXdp(t *testing.T) int {
+	t.Helper()
+	ctx, cancel := s.ctx()
+	defer cancel()
+	progType := uint32(builder.Xdp)
+	res, err := s.c.List(ctx, &gobpfman.ListRequest{ProgramType: &progType})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	n := 0
+	for _, r := range res.GetResults() {
+		if r.GetInfo().GetAttach().GetXdpAttachInfo().GetIface() == s.cfg.Iface {
+			n++
+		}
+	}
+	return n
+}
+
+// tracepoint returns a builder for the configured tracepoint program, tagged
+// with the run ID.
+func (s *suite) tracepoint() *builder.TracepointLoadBuilder {
+	return builder.NewTracepointLoad().
+		FromBytecode(s.cfg.Bytecode).
+		Name(s.cfg.TracepointProgram).
+		Tracepoint(s.cfg.Tracepoint).
+		Metadata(RunMetadataKey, s.runID)
+}
+
+// load sends req and registers the program for unloading when the subtest
+// ends.
+func (s *suite) load(t *testing.T, req *gobpfman.LoadRequest) *gobpfman.LoadResponse {
+	t.Helper()
+	ctx, cancel := s.ctx()
+	defer cancel()
+	res, err := s.c.Load(ctx, req)
+	if err != nil {
+		t.Fatalf("Load(%s) failed: %v", req.GetName(), err)
+	}
+	s.cleanup(t, res.GetKernelInfo().GetId())
+	return res
+}
+
+func (s *suite) unload(t *testing.T, id uint32) {
+	t.Helper()
+	ctx, cancel := s.ctx()
+	defer cancel()
+	if _, err := s.c.Unload(ctx, &gobpfman.UnloadRequest{Id: id}); err != nil {
+		t.Fatalf("Unload(%d) failed: %v", id, err)
+	}
+}
+
+// cleanup unloads id at the end of the subtest, ignoring programs the test
+// already unloaded.
+func (s *suite) cleanup(t *testing.T, id uint32) {
+	t.Cleanup(func() {
+		ctx, cancel := s.ctx()
+		defer cancel()
+		get, err := s.c.Get(ctx, &gobpfman.GetRequest{Id: id})
+		if err != nil || get.GetInfo().GetMetadata()[RunMetadataKey] != s.runID {
+			return
+		}
+		if _, err := s.c.Unload(ctx, &gobpfman.UnloadRequest{Id: id}); err != nil {
+			t.Logf("failed to unload program %d: %v", id, err)
+		}
+	})
+}
+
+func (s *suite) list(t *testing.T, req *gobpfman.ListRequest) []uint32 {
+	t.Helper()
+	ctx, cancel := s.ctx()
+	defer cancel()
+	res, err := s.c.List(ctx, req)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	ids := []uint32{}
+	for _, r := range res.GetResults() {
+		ids = append(ids, r.GetKernelInfo().GetId())
+	}
+	return ids
+}
+
+func (s *suite) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.cfg.Timeout)
+}
+
+func must(t *testing.T, build func() (*gobpfman.LoadRequest, error)) *gobpfman.LoadRequest {
+	t.Helper()
+	req, err := build()
+	if err != nil {
+		t.Fatalf("invalid conformance configuration: %v", err)
+	}
+	return req
+}
@@ -0,0 +1,555 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakebpfman provides an in-memory implementation of the bpfman gRPC
+// service, so programs built on the gobpfman client can be tested without a
+// kernel or a running bpfman daemon.
+//
+// The fake keeps the bookkeeping bpfman exposes over the API: kernel IDs,
+// map owner sharing and map pin paths, dispatcher positions and slot limits
+// for XDP, TC and TCX programs, and List filtering. It does not load any
+// bytecode, and only rejects requests bpfman itself would reject. Failures
+// and latency can be injected per RPC.
+//
+//	s := fakebpfman.New()
+//	conn, err := s.Start()
+//	if err != nil {
+//		...
+//	}
+//	defer s.Stop()
+//	c := gobpfman.NewBpfmanClient(conn)
+package fakebpfman
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"github.com/bpfman/bpfman/clients/gobpfman/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MapPinPathPrefix is the directory under which bpfman pins the maps of
+	// each map owner.
+	MapPinPathPrefix = "/run/bpfman/fs/maps"
+
+	// MaxDispatcherPrograms is the number of XDP or TC programs a single
+	// dispatcher can hold. TCX has no dispatcher and no limit.
+	MaxDispatcherPrograms = 10
+
+	bufSize = 1024 * 1024
+)
+
+// RPC names a method of the bpfman service, for injecting failures and
+// inspecting call counts.
+type RPC string
+
+const (
+	RPCLoad         RPC = "Load"
+	RPCUnload       RPC = "Unload"
+	RPCList         RPC = "List"
+	RPCPullBytecode RPC = "PullBytecode"
+	RPCGet          RPC = "Get"
+)
+
+// Server is a fake bpfman gRPC server. The zero value is not usable; create
+// one with New.
+type Server struct {
+	gobpfman.UnimplementedBpfmanServer
+
+	mu       sync.Mutex
+	nextID   uint32
+	programs map[uint32]*gobpfman.ListResponse_ListResult
+	// maps tracks, per map owner ID, the IDs of programs sharing its maps.
+	// Like bpfman, entries outlive the owner until the last user is
+	// unloaded.
+	maps    map[uint32][]uint32
+	errs    map[RPC]error
+	latency map[RPC]time.Duration
+	calls   map[RPC]int
+	images  []string
+
+	grpcServer *grpc.Server
+}
+
+// New returns an empty fake bpfman server.
+func New() *Server {
+	return &Server{
+		nextID:   1,
+		programs: map[uint32]*gobpfman.ListResponse_ListResult{},
+		maps:     map[uint32][]uint32{},
+		errs:     map[RPC]error{},
+		latency:  map[RPC]time.Duration{},
+		calls:    map[RPC]int{},
+	}
+}
+
+// Start serves the fake on an in-memory listener and returns a client
+// connection to it. The caller should close the connection and call Stop when
+// done.
+func (s *Server) Start() (*grpc.ClientConn, error) {
+	lis := bufconn.Listen(bufSize)
+	s.grpcServer = grpc.NewServer()
+	gobpfman.RegisterBpfmanServer(s.grpcServer, s)
+	go func() {
+		_ = s.grpcServer.Serve(lis)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///fakebpfman",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		s.grpcServer.Stop()
+		return nil, fmt.Errorf("unable to connect to fake bpfman: %v", err)
+	}
+	return conn, nil
+}
+
+// Stop shuts down a server started with Start.
+func (s *Server) Stop() {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+}
+
+// SetError makes every subsequent call to rpc fail with err, until cleared
+// with a nil err. Errors that do not carry a gRPC status are returned with
+// codes.Aborted, as bpfman does.
+func (s *Server) SetError(rpc RPC, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errs, rpc)
+		return
+	}
+	if _, ok := status.FromError(err); !ok {
+		err = status.Error(codes.Aborted, err.Error())
+	}
+	s.errs[rpc] = err
+}
+
+// SetLatency delays every subsequent call to rpc by d. A call gives up early
+// if its context is done.
+func (s *Server) SetLatency(rpc RPC, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency[rpc] = d
+}
+
+// Calls returns how many times rpc has been called, including calls that
+// failed.
+func (s *Server) Calls(rpc RPC) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[rpc]
+}
+
+// PulledImages returns the URLs passed to PullBytecode, in call order.
+func (s *Server) PulledImages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.images...)
+}
+
+// Programs returns a copy of every program the fake knows about, ordered by
+// kernel ID.
+func (s *Server) Programs() []*gobpfman.ListResponse_ListResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedLocked(func(*gobpfman.ListResponse_ListResult) bool { return true })
+}
+
+// AddKernelProgram records a program that was loaded outside bpfman. It is
+// returned by List and Get with no ProgramInfo, and cannot be unloaded. The
+// ID in info is replaced with the next free kernel ID, which is returned.
+func (s *Server) AddKernelProgram(info *gobpfman.KernelProgramInfo) uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kernelInfo := proto.Clone(info).(*gobpfman.KernelProgramInfo)
+	kernelInfo.Id = s.nextID
+	s.nextID++
+	s.programs[kernelInfo.Id] = &gobpfman.ListResponse_ListResult{KernelInfo: kernelInfo}
+	return kernelInfo.Id
+}
+
+func (s *Server) Load(ctx context.Context, req *gobpfman.LoadRequest) (*gobpfman.LoadResponse, error) {
+	if err := s.begin(ctx, RPCLoad); err != nil {
+		return nil, err
+	}
+	if err := checkLoadRequest(req); err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	point, chained := attachPointOf(req.GetAttach())
+	if chained && point.kind != "tcx" && len(s.chainLocked(point)) >= MaxDispatcherPrograms {
+		return nil, status.Error(codes.Aborted, "No room to attach program. Please remove one and try again.")
+	}
+
+	id := s.nextID
+	mapIndex := id
+	if req.MapOwnerId != nil {
+		mapIndex = req.GetMapOwnerId()
+		if _, ok := s.maps[mapIndex]; !ok {
+			return nil, status.Error(codes.Aborted, "map_owner_id does not exist")
+		}
+	}
+	s.nextID++
+	s.maps[mapIndex] = append(s.maps[mapIndex], id)
+
+	info := &gobpfman.ProgramInfo{
+		Name:       req.GetName(),
+		Bytecode:   proto.Clone(req.GetBytecode()).(*gobpfman.BytecodeLocation),
+		Attach:     proto.Clone(req.GetAttach()).(*gobpfman.AttachInfo),
+		GlobalData: cloneBytesMap(req.GetGlobalData()),
+		MapOwnerId: req.MapOwnerId,
+		MapPinPath: fmt.Sprintf("%s/%d", MapPinPathPrefix, mapIndex),
+		Metadata:   cloneStringMap(req.GetMetadata()),
+	}
+	name := req.GetName()
+	if len(name) > 15 {
+		// The kernel truncates program names to 16 bytes including the
+		// terminating NUL.
+		name = name[:15]
+	}
+	kernelInfo := &gobpfman.KernelProgramInfo{
+		Id:            id,
+		Name:          name,
+		ProgramType:   req.GetProgramType(),
+		LoadedAt:      time.Now().Format("2006-01-02T15:04:05-0700"),
+		Tag:           fmt.Sprintf("%016x", id),
+		GplCompatible: true,
+	}
+	s.programs[id] = &gobpfman.ListResponse_ListResult{Info: info, KernelInfo: kernelInfo}
+	s.updateMapUsersLocked(mapIndex)
+	if chained {
+		s.updatePositionsLocked(point, id)
+	}
+
+	res := s.programs[id]
+	return &gobpfman.LoadResponse{
+		Info:       proto.Clone(res.GetInfo()).(*gobpfman.ProgramInfo),
+		KernelInfo: proto.Clone(res.GetKernelInfo()).(*gobpfman.KernelProgramInfo),
+	}, nil
+}
+
+func (s *Server) Unload(ctx context.Context, req *gobpfman.UnloadRequest) (*gobpfman.UnloadResponse, error) {
+	if err := s.begin(ctx, RPCUnload); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := req.GetId()
+	prog, ok := s.programs[id]
+	if !ok || prog.GetInfo() == nil {
+		return nil, status.Errorf(codes.Aborted, "Program %d does not exist or was not created by bpfman", id)
+	}
+	delete(s.programs, id)
+
+	mapIndex := id
+	if prog.GetInfo().MapOwnerId != nil {
+		mapIndex = prog.GetInfo().GetMapOwnerId()
+	}
+	usedBy := s.maps[mapIndex]
+	for i, u := range usedBy {
+		if u == id {
+			usedBy = append(usedBy[:i], usedBy[i+1:]...)
+			break
+		}
+	}
+	if len(usedBy) == 0 {
+		delete(s.maps, mapIndex)
+	} else {
+		s.maps[mapIndex] = usedBy
+		s.updateMapUsersLocked(mapIndex)
+	}
+	if point, chained := attachPointOf(prog.GetInfo().GetAttach()); chained {
+		s.updatePositionsLocked(point, 0)
+	}
+
+	return &gobpfman.UnloadResponse{}, nil
+}
+
+func (s *Server) List(ctx context.Context, req *gobpfman.ListRequest) (*gobpfman.ListResponse, error) {
+	if err := s.begin(ctx, RPCList); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := s.sortedLocked(func(r *gobpfman.ListResponse_ListResult) bool {
+		if req.ProgramType != nil && r.GetKernelInfo().GetProgramType() != req.GetProgramType() {
+			return false
+		}
+		if r.GetInfo() == nil {
+			// Programs not loaded by bpfman carry no metadata, so any
+			// metadata filter excludes them.
+			return !req.GetBpfmanProgramsOnly() && len(req.GetMatchMetadata()) == 0
+		}
+		for k, v := range req.GetMatchMetadata() {
+			if r.GetInfo().GetMetadata()[k] != v {
+				return false
+			}
+		}
+		return true
+	})
+	return &gobpfman.ListResponse{Results: results}, nil
+}
+
+func (s *Server) PullBytecode(ctx context.Context, req *gobpfman.PullBytecodeRequest) (*gobpfman.PullBytecodeResponse, error) {
+	if err := s.begin(ctx, RPCPullBytecode); err != nil {
+		return nil, err
+	}
+	if req.GetImage() == nil {
+		return nil, status.Error(codes.Aborted, "Empty pull_bytecode request received")
+	}
+	if len(req.GetImage().GetUrl()) == 0 {
+		return nil, status.Error(codes.Aborted, "image url is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images = append(s.images, req.GetImage().GetUrl())
+	return &gobpfman.PullBytecodeResponse{}, nil
+}
+
+func (s *Server) Get(ctx context.Context, req *gobpfman.GetRequest) (*gobpfman.GetResponse, error) {
+	if err := s.begin(ctx, RPCGet); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prog, ok := s.programs[req.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.Aborted, "Program %d does not exist", req.GetId())
+	}
+	prog = proto.Clone(prog).(*gobpfman.ListResponse_ListResult)
+	return &gobpfman.GetResponse{Info: prog.GetInfo(), KernelInfo: prog.GetKernelInfo()}, nil
+}
+
+// begin counts the call, applies any configured latency and returns any
+// configured error for rpc.
+func (s *Server) begin(ctx context.Context, rpc RPC) error {
+	s.mu.Lock()
+	s.calls[rpc]++
+	delay := s.latency[rpc]
+	err := s.errs[rpc]
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return err
+}
+
+func (s *Server) sortedLocked(keep func(*gobpfman.ListResponse_ListResult) bool) []*gobpfman.ListResponse_ListResult {
+	results := []*gobpfman.ListResponse_ListResult{}
+	for _, r := range s.programs {
+		if keep(r) {
+			results = append(results, proto.Clone(r).(*gobpfman.ListResponse_ListResult))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].GetKernelInfo().GetId() < results[j].GetKernelInfo().GetId()
+	})
+	return results
+}
+
+// updateMapUsersLocked refreshes MapUsedBy on every program sharing the maps
+// of mapIndex.
+func (s *Server) updateMapUsersLocked(mapIndex uint32) {
+	usedBy := make([]string, 0, len(s.maps[mapIndex]))
+	for _, id := range s.maps[mapIndex] {
+		usedBy = append(usedBy, strconv.FormatUint(uint64(id), 10))
+	}
+	for _, id := range s.maps[mapIndex] {
+		if prog, ok := s.programs[id]; ok && prog.GetInfo() != nil {
+			prog.Info.MapUsedBy = append([]string(nil), usedBy...)
+		}
+	}
+}
+
+// attachPoint identifies a dispatcher or TCX chain that orders programs by
+// priority.
+type attachPoint struct {
+	kind      string
+	iface     string
+	direction string
+	netns     string
+}
+
+// attachPointOf returns the chain a program attaches to, if it is an XDP, TC
+// or TCX program.
+func attachPointOf(attach *gobpfman.AttachInfo) (attachPoint, bool) {
+	switch {
+	case attach.GetXdpAttachInfo() != nil:
+		a := attach.GetXdpAttachInfo()
+		return attachPoint{kind: "xdp", iface: a.GetIface(), netns: a.GetNetns()}, true
+	case attach.GetTcAttachInfo() != nil:
+		a := attach.GetTcAttachInfo()
+		return attachPoint{kind: "tc", iface: a.GetIface(), direction: a.GetDirection(), netns: a.GetNetns()}, true
+	case attach.GetTcxAttachInfo() != nil:
+		a := attach.GetTcxAttachInfo()
+		return attachPoint{kind: "tcx", iface: a.GetIface(), direction: a.GetDirection(), netns: a.GetNetns()}, true
+	default:
+		return attachPoint{}, false
+	}
+}
+
+// chainLocked returns the programs attached to point.
+func (s *Server) chainLocked(point attachPoint) []*gobpfman.ListResponse_ListResult {
+	chain := []*gobpfman.ListResponse_ListResult{}
+	for _, prog := range s.programs {
+		if p, ok := attachPointOf(prog.GetInfo().GetAttach()); ok && p == point {
+			chain = append(chain, prog)
+		}
+	}
+	return chain
+}
+
+// updatePositionsLocked recomputes the positions in the chain at point after
+// newID is loaded into it, or after a program is unloaded from it if newID is
+// zero. Like bpfman, XDP and TC programs are ordered by priority, then with
+// the program being loaded ahead of those already attached, then by name.
+// TCX programs are ordered by priority, then keep their existing order, with
+// a new program placed last.
+func (s *Server) updatePositionsLocked(point attachPoint, newID uint32) {
+	chain := s.chainLocked(point)
+	sort.Slice(chain, func(i, j int) bool {
+		a, b := chain[i], chain[j]
+		if pa, pb := priority(a), priority(b); pa != pb {
+			return pa < pb
+		}
+		ia, ib := a.GetKernelInfo().GetId(), b.GetKernelInfo().GetId()
+		if point.kind == "tcx" {
+			return ia < ib
+		}
+		if na, nb := ia == newID, ib == newID; na != nb {
+			return na
+		}
+		if a.GetInfo().GetName() != b.GetInfo().GetName() {
+			return a.GetInfo().GetName() < b.GetInfo().GetName()
+		}
+		return ia < ib
+	})
+	for pos, prog := range chain {
+		attach := prog.GetInfo().GetAttach()
+		switch {
+		case attach.GetXdpAttachInfo() != nil:
+			attach.GetXdpAttachInfo().Position = int32(pos)
+		case attach.GetTcAttachInfo() != nil:
+			attach.GetTcAttachInfo().Position = int32(pos)
+		case attach.GetTcxAttachInfo() != nil:
+			attach.GetTcxAttachInfo().Position = int32(pos)
+		}
+	}
+}
+
+func priority(prog *gobpfman.ListResponse_ListResult) int32 {
+	attach := prog.GetInfo().GetAttach()
+	switch {
+	case attach.GetXdpAttachInfo() != nil:
+		return attach.GetXdpAttachInfo().GetPriority()
+	case attach.GetTcAttachInfo() != nil:
+		return attach.GetTcAttachInfo().GetPriority()
+	default:
+		return attach.GetTcxAttachInfo().GetPriority()
+	}
+}
+
+// checkLoadRequest applies the checks bpfman itself makes on a LoadRequest.
+// The priority range and other rules from the bpfman-operator CRD schema,
+// enforced by validation.ValidateLoadRequest, are left to clients.
+func checkLoadRequest(req *gobpfman.LoadRequest) error {
+	switch location := req.GetBytecode().GetLocation().(type) {
+	case *gobpfman.BytecodeLocation_Image:
+		if len(location.Image.GetUrl()) == 0 {
+			return fmt.Errorf("image url is required")
+		}
+	case *gobpfman.BytecodeLocation_File:
+		if len(location.File) == 0 {
+			return fmt.Errorf("bytecode file path is required")
+		}
+	default:
+		return fmt.Errorf("bytecode source is required")
+	}
+	if len(req.GetName()) == 0 {
+		return fmt.Errorf("program name is required")
+	}
+
+	attach := req.GetAttach()
+	switch {
+	case attach.GetXdpAttachInfo() != nil:
+		return validation.ValidateXdpProceedOn(attach.GetXdpAttachInfo().GetProceedOn())
+	case attach.GetTcAttachInfo() != nil:
+		if err := validation.ValidateDirection(attach.GetTcAttachInfo().GetDirection()); err != nil {
+			return err
+		}
+		return validation.ValidateTcProceedOn(attach.GetTcAttachInfo().GetProceedOn())
+	case attach.GetTcxAttachInfo() != nil:
+		return validation.ValidateDirection(attach.GetTcxAttachInfo().GetDirection())
+	case attach.GetInfo() == nil:
+		return fmt.Errorf("attach info is required")
+	}
+	return nil
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneBytesMap(m map[string][]byte) map[string][]byte {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string][]byte, len(m))
+	for k, v := range m {
+		c[k] = append([]byte(nil), v...)
+	}
+	return c
+}
@@ -0,0 +1,464 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakebpfman
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
+	"github.com/bpfman/bpfman/clients/gobpfman/conformance"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func startServer(t *testing.T) (*Server, gobpfman.BpfmanClient) {
+	t.Helper()
+	s := New()
+	conn, err := s.Start()
+	if err != nil {
+		t.Fatalf("failed to start fake bpfman: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return s, gobpfman.NewBpfmanClient(conn)
+}
+
+func tracepointRequest(t *testing.T, mapOwner uint32) *gobpfman.LoadRequest {
+	t.Helper()
+	req, err := builder.NewTracepointLoad().
+		FromFile("/tmp/tracepoint.o").
+		Name("tracepoint_kill_recorder").
+		Tracepoint("syscalls/sys_enter_kill").
+		MapOwner(mapOwner).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	return req
+}
+
+func xdpRequest(t *testing.T, iface, name string, priority int32) *gobpfman.LoadRequest {
+	t.Helper()
+	req, err := builder.NewXdpLoad().
+		FromFile("/tmp/xdp.o").
+		Name(name).
+		Iface(iface).
+		Priority(priority).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	return req
+}
+
+func load(t *testing.T, c gobpfman.BpfmanClient, req *gobpfman.LoadRequest) uint32 {
+	t.Helper()
+	res, err := c.Load(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return res.GetKernelInfo().GetId()
+}
+
+func get(t *testing.T, c gobpfman.BpfmanClient, id uint32) *gobpfman.GetResponse {
+	t.Helper()
+	res, err := c.Get(context.Background(), &gobpfman.GetRequest{Id: id})
+	if err != nil {
+		t.Fatalf("Get(%d) failed: %v", id, err)
+	}
+	return res
+}
+
+func unload(t *testing.T, c gobpfman.BpfmanClient, id uint32) {
+	t.Helper()
+	if _, err := c.Unload(context.Background(), &gobpfman.UnloadRequest{Id: id}); err != nil {
+		t.Fatalf("Unload(%d) failed: %v", id, err)
+	}
+}
+
+// TestConformance keeps the fake in line with the behaviour the conformance
+// suite expects from bpfman.
+func TestConformance(t *testing.T) {
+	_, c := startServer(t)
+	conformance.Run(t, c, conformance.Config{
+		Bytecode: &gobpfman.BytecodeLocation{
+			Location: &gobpfman.BytecodeLocation_File{File: "/tmp/prog.o"},
+		},
+		TracepointProgram: "tracepoint_kill_recorder",
+		Tracepoint:        "syscalls/sys_enter_kill",
+		XdpProgram:        "xdp_stats",
+		Iface:             "eth0",
+	})
+}
+
+func TestMapOwner(t *testing.T) {
+	s, c := startServer(t)
+
+	owner := load(t, c, tracepointRequest(t, 0))
+	user := load(t, c, tracepointRequest(t, owner))
+
+	ownerInfo, userInfo := get(t, c, owner).GetInfo(), get(t, c, user).GetInfo()
+	if ownerInfo.MapOwnerId != nil {
+		t.Errorf("owner has map owner %d", ownerInfo.GetMapOwnerId())
+	}
+	if userInfo.GetMapOwnerId() != owner {
+		t.Errorf("user has map owner %d, expected %d", userInfo.GetMapOwnerId(), owner)
+	}
+	if ownerInfo.GetMapPinPath() != "/run/bpfman/fs/maps/1" || userInfo.GetMapPinPath() != ownerInfo.GetMapPinPath() {
+		t.Errorf("map pin paths are %q and %q, expected both to be /run/bpfman/fs/maps/1",
+			ownerInfo.GetMapPinPath(), userInfo.GetMapPinPath())
+	}
+	for _, info := range []*gobpfman.ProgramInfo{ownerInfo, userInfo} {
+		if !slices.Equal(info.GetMapUsedBy(), []string{"1", "2"}) {
+			t.Errorf("map_used_by is %v, expected [1 2]", info.GetMapUsedBy())
+		}
+	}
+
+	_, err := c.Load(context.Background(), tracepointRequest(t, 99))
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("Load with unknown map owner returned %v, expected Aborted", err)
+	}
+
+	// The user keeps the owner's maps after the owner is unloaded.
+	unload(t, c, owner)
+	userInfo = get(t, c, user).GetInfo()
+	if userInfo.GetMapPinPath() != ownerInfo.GetMapPinPath() {
+		t.Errorf("map pin path changed to %q after unloading the owner", userInfo.GetMapPinPath())
+	}
+	if !slices.Equal(userInfo.GetMapUsedBy(), []string{"2"}) {
+		t.Errorf("map_used_by is %v after unloading the owner, expected [2]", userInfo.GetMapUsedBy())
+	}
+	// While the user remains, the maps can still be shared.
+	third := load(t, c, tracepointRequest(t, owner))
+	if got := get(t, c, third).GetInfo().GetMapUsedBy(); !slices.Equal(got, []string{"2", "3"}) {
+		t.Errorf("map_used_by is %v, expected [2 3]", got)
+	}
+
+	// Once the last user is gone the maps are released.
+	unload(t, c, user)
+	unload(t, c, third)
+	if _, err := c.Load(context.Background(), tracepointRequest(t, owner)); err == nil {
+		t.Errorf("Load sharing released maps succeeded")
+	}
+	if n := len(s.Programs()); n != 0 {
+		t.Errorf("%d programs left after unloading everything", n)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	_, c := startServer(t)
+
+	positions := func() map[uint32]int32 {
+		p := map[uint32]int32{}
+		res, err := c.List(context.Background(), &gobpfman.ListRequest{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		for _, r := range res.GetResults() {
+			attach := r.GetInfo().GetAttach()
+			if tcx := attach.GetTcxAttachInfo(); tcx != nil {
+				p[r.GetKernelInfo().GetId()] = tcx.GetPosition()
+			} else {
+				p[r.GetKernelInfo().GetId()] = attach.GetXdpAttachInfo().GetPosition()
+			}
+		}
+		return p
+	}
+
+	a := load(t, c, xdpRequest(t, "eth0", "xdp_b", 50))
+	b := load(t, c, xdpRequest(t, "eth0", "xdp_a", 10))
+	other := load(t, c, xdpRequest(t, "eth1", "xdp_a", 100))
+	// Lower priority first. At equal priority, bpfman places the program
+	// being loaded ahead of those already attached, whatever its name.
+	d := load(t, c, xdpRequest(t, "eth0", "xdp_c", 50))
+	want := map[uint32]int32{b: 0, d: 1, a: 2, other: 0}
+	if got := positions(); !equalPositions(got, want) {
+		t.Errorf("positions are %v, expected %v", got, want)
+	}
+
+	// An unload reorders the remaining programs by priority, then name.
+	unload(t, c, b)
+	want = map[uint32]int32{a: 0, d: 1, other: 0}
+	if got := positions(); !equalPositions(got, want) {
+		t.Errorf("positions after unload are %v, expected %v", got, want)
+	}
+	unload(t, c, a)
+	unload(t, c, d)
+	unload(t, c, other)
+
+	// TCX keeps load order at equal priority.
+	tcx := func(name string, priority int32) uint32 {
+		req, err := builder.NewTcxLoad().
+			FromFile("/tmp/tcx.o").
+			Name(name).
+			Iface("eth0").
+			Direction(builder.Ingress).
+			Priority(priority).
+			Build()
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		return load(t, c, req)
+	}
+	x := tcx("tcx_c", 50)
+	y := tcx("tcx_b", 50)
+	z := tcx("tcx_a", 10)
+	want = map[uint32]int32{z: 0, x: 1, y: 2}
+	if got := positions(); !equalPositions(got, want) {
+		t.Errorf("TCX positions are %v, expected %v", got, want)
+	}
+	unload(t, c, z)
+	want = map[uint32]int32{x: 0, y: 1}
+	if got := positions(); !equalPositions(got, want) {
+		t.Errorf("TCX positions after unload are %v, expected %v", got, want)
+	}
+
+	// TC chains are per direction.
+	for _, dir := range []builder.Direction{builder.Ingress, builder.Egress} {
+		req, err := builder.NewTcLoad().
+			FromFile("/tmp/tc.o").
+			Name("stats").
+			Iface("eth0").
+			Direction(dir).
+			Priority(500).
+			Build()
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		id := load(t, c, req)
+		if pos := get(t, c, id).GetInfo().GetAttach().GetTcAttachInfo().GetPosition(); pos != 0 {
+			t.Errorf("%s TC program is at position %d, expected 0", dir, pos)
+		}
+	}
+}
+
+func TestDispatcherLimit(t *testing.T) {
+	_, c := startServer(t)
+	ctx := context.Background()
+
+	ids := []uint32{}
+	for i := 0; i < MaxDispatcherPrograms; i++ {
+		ids = append(ids, load(t, c, xdpRequest(t, "eth0", "xdp_stats", 50)))
+	}
+	_, err := c.Load(ctx, xdpRequest(t, "eth0", "xdp_stats", 50))
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("Load of program %d on eth0 returned %v, expected Aborted", MaxDispatcherPrograms+1, err)
+	}
+
+	// The limit is per dispatcher.
+	load(t, c, xdpRequest(t, "eth1", "xdp_stats", 50))
+	unload(t, c, ids[0])
+	load(t, c, xdpRequest(t, "eth0", "xdp_stats", 50))
+
+	tc := func(direction builder.Direction) *gobpfman.LoadRequest {
+		req, err := builder.NewTcLoad().
+			FromFile("/tmp/tc.o").
+			Name("stats").
+			Iface("eth0").
+			Direction(direction).
+			Build()
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		return req
+	}
+	for i := 0; i < MaxDispatcherPrograms; i++ {
+		load(t, c, tc(builder.Ingress))
+	}
+	if _, err := c.Load(ctx, tc(builder.Ingress)); status.Code(err) != codes.Aborted {
+		t.Errorf("Load of TC program %d on eth0 ingress returned %v, expected Aborted", MaxDispatcherPrograms+1, err)
+	}
+	load(t, c, tc(builder.Egress))
+
+	// TCX programs do not use a dispatcher.
+	for i := 0; i <= MaxDispatcherPrograms; i++ {
+		req, err := builder.NewTcxLoad().
+			FromFile("/tmp/tcx.o").
+			Name("tcx_stats").
+			Iface("eth0").
+			Direction(builder.Ingress).
+			Build()
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+		load(t, c, req)
+	}
+}
+
+// TestDaemonChecksOnly checks that the fake accepts requests bpfman accepts,
+// even where they break the bpfman-operator CRD rules.
+func TestDaemonChecksOnly(t *testing.T) {
+	_, c := startServer(t)
+
+	for _, priority := range []int32{-1, 1001} {
+		req := xdpRequest(t, "eth0", "xdp_stats", 50)
+		req.GetAttach().GetXdpAttachInfo().Priority = priority
+		id := load(t, c, req)
+		if got := get(t, c, id).GetInfo().GetAttach().GetXdpAttachInfo().GetPriority(); got != priority {
+			t.Errorf("priority is %d, expected %d", got, priority)
+		}
+	}
+
+	for name, req := range map[string]*gobpfman.LoadRequest{
+		"no bytecode": {Name: "xdp_stats", ProgramType: 6, Attach: xdpRequest(t, "eth0", "xdp_stats", 50).Attach},
+		"no name":     {Bytecode: xdpRequest(t, "eth0", "xdp_stats", 50).Bytecode, Attach: xdpRequest(t, "eth0", "xdp_stats", 50).Attach},
+		"no attach":   {Bytecode: xdpRequest(t, "eth0", "xdp_stats", 50).Bytecode, Name: "xdp_stats"},
+		"bad proceed-on": func() *gobpfman.LoadRequest {
+			req := xdpRequest(t, "eth0", "xdp_stats", 50)
+			req.GetAttach().GetXdpAttachInfo().ProceedOn = []int32{30}
+			return req
+		}(),
+	} {
+		if _, err := c.Load(context.Background(), req); status.Code(err) != codes.Aborted {
+			t.Errorf("Load with %s returned %v, expected Aborted", name, err)
+		}
+	}
+}
+
+func equalPositions(got, want map[uint32]int32) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for id, pos := range want {
+		if p, ok := got[id]; !ok || p != pos {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSetError(t *testing.T) {
+	s, c := startServer(t)
+	ctx := context.Background()
+
+	s.SetError(RPCLoad, errors.New("no space left on device"))
+	_, err := c.Load(ctx, tracepointRequest(t, 0))
+	if st, _ := status.FromError(err); st.Code() != codes.Aborted || st.Message() != "no space left on device" {
+		t.Errorf("Load returned %v, expected Aborted: no space left on device", err)
+	}
+
+	// Errors that already carry a status keep their code.
+	s.SetError(RPCGet, status.Error(codes.Unavailable, "restarting"))
+	if _, err := c.Get(ctx, &gobpfman.GetRequest{Id: 1}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Get returned %v, expected Unavailable", err)
+	}
+
+	s.SetError(RPCLoad, nil)
+	load(t, c, tracepointRequest(t, 0))
+	if n := s.Calls(RPCLoad); n != 2 {
+		t.Errorf("Calls(Load) = %d, expected 2 including the failed call", n)
+	}
+}
+
+func TestSetLatency(t *testing.T) {
+	s, c := startServer(t)
+
+	s.SetLatency(RPCList, time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.List(ctx, &gobpfman.ListRequest{})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("List returned %v, expected DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("List took %s, expected it to stop when the context expired", elapsed)
+	}
+
+	s.SetLatency(RPCList, 20*time.Millisecond)
+	start = time.Now()
+	if _, err := c.List(context.Background(), &gobpfman.ListRequest{}); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("List took %s, expected at least 20ms", elapsed)
+	}
+}
+
+func TestListAndKernelPrograms(t *testing.T) {
+	s, c := startServer(t)
+	ctx := context.Background()
+
+	kernel := s.AddKernelProgram(&gobpfman.KernelProgramInfo{Name: "sd_fw_ingress", ProgramType: 8})
+	req := tracepointRequest(t, 0)
+	req.Name = "a_name_longer_than_fifteen"
+	req.Metadata = map[string]string{"app": "counter"}
+	tp := load(t, c, req)
+	xdp := load(t, c, xdpRequest(t, "eth0", "xdp_stats", 50))
+
+	if got := get(t, c, tp).GetKernelInfo().GetName(); got != "a_name_longer_t" {
+		t.Errorf("kernel name is %q, expected it truncated to 15 characters", got)
+	}
+
+	list := func(req *gobpfman.ListRequest) []uint32 {
+		res, err := c.List(ctx, req)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		ids := []uint32{}
+		for _, r := range res.GetResults() {
+			ids = append(ids, r.GetKernelInfo().GetId())
+		}
+		return ids
+	}
+	xdpType := uint32(builder.Xdp)
+	bpfmanOnly := true
+	for _, tt := range []struct {
+		name string
+		req  *gobpfman.ListRequest
+		want []uint32
+	}{
+		{"all", &gobpfman.ListRequest{}, []uint32{kernel, tp, xdp}},
+		{"program type", &gobpfman.ListRequest{ProgramType: &xdpType}, []uint32{xdp}},
+		{"metadata", &gobpfman.ListRequest{MatchMetadata: map[string]string{"app": "counter"}}, []uint32{tp}},
+		{"bpfman only", &gobpfman.ListRequest{BpfmanProgramsOnly: &bpfmanOnly}, []uint32{tp, xdp}},
+	} {
+		if got := list(tt.req); !slices.Equal(got, tt.want) {
+			t.Errorf("List by %s returned %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+
+	if res := get(t, c, kernel); res.GetInfo() != nil {
+		t.Errorf("Get returned program info for a program not loaded by bpfman")
+	}
+	if _, err := c.Unload(ctx, &gobpfman.UnloadRequest{Id: kernel}); status.Code(err) != codes.Aborted {
+		t.Errorf("Unload of a program not loaded by bpfman returned %v, expected Aborted", err)
+	}
+}
+
+func TestPullBytecode(t *testing.T) {
+	s, c := startServer(t)
+	ctx := context.Background()
+
+	url := "quay.io/bpfman-bytecode/go-xdp-counter:latest"
+	if _, err := c.PullBytecode(ctx, &gobpfman.PullBytecodeRequest{Image: &gobpfman.BytecodeImage{Url: url}}); err != nil {
+		t.Fatalf("PullBytecode failed: %v", err)
+	}
+	if _, err := c.PullBytecode(ctx, &gobpfman.PullBytecodeRequest{}); status.Code(err) != codes.Aborted {
+		t.Errorf("PullBytecode without an image returned %v, expected Aborted", err)
+	}
+	if got := s.PulledImages(); !slices.Equal(got, []string{url}) {
+		t.Errorf("PulledImages() = %v, expected [%s]", got, url)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package globaldata encodes and decodes the values of eBPF global variables
+// carried in the GlobalData field of bpfman LoadRequests and ProgramInfo.
+//
+// Integers are encoded in the host's native byte order, matching how the
+// kernel lays out the program's .data/.rodata sections on the node running
+// bpfman. Addresses are encoded in network byte order, the form eBPF programs
+// compare them against in packet headers.
+package globaldata
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+// Get returns the global data bpfman applied when it loaded the program with
+// the given kernel ID.
+func Get(ctx context.Context, c gobpfman.BpfmanClient, id uint32) (map[string][]byte, error) {
+	res, err := c.Get(ctx, &gobpfman.GetRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	info := res.GetInfo()
+	if info == nil {
+		return nil, fmt.Errorf("program %d is not managed by bpfman", id)
+	}
+	return info.GetGlobalData(), nil
+}
+
+// Bool encodes a bool as a single byte.
+func Bool(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// Uint8 encodes a u8.
+func Uint8(v uint8) []byte {
+	return []byte{v}
+}
+
+// Uint16 encodes a u16.
+func Uint16(v uint16) []byte {
+	return binary.NativeEndian.AppendUint16(nil, v)
+}
+
+// Uint32 encodes a u32.
+func Uint32(v uint32) []byte {
+	return binary.NativeEndian.AppendUint32(nil, v)
+}
+
+// Uint64 encodes a u64.
+func Uint64(v uint64) []byte {
+	return binary.NativeEndian.AppendUint64(nil, v)
+}
+
+// IPv4 encodes an IPv4 address as a __be32.
+func IPv4(ip net.IP) ([]byte, error) {
+	v4 := ip.To4()
+	if v4 == nil {
+		return nil, fmt.Errorf("%q is not an IPv4 address", ip)
+	}
+	return append([]byte(nil), v4...), nil
+}
+
+// IPv6 encodes an IP address as 16 bytes in network byte order. IPv4
+// addresses are encoded in their IPv4-mapped form, ::ffff:a.b.c.d.
+func IPv6(ip net.IP) ([]byte, error) {
+	v6 := ip.To16()
+	if v6 == nil {
+		return nil, fmt.Errorf("%q is not an IP address", ip)
+	}
+	return append([]byte(nil), v6...), nil
+}
+
+// MAC encodes an Ethernet hardware address as 6 bytes.
+func MAC(mac net.HardwareAddr) ([]byte, error) {
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("%q is not an Ethernet MAC address", mac)
+	}
+	return append([]byte(nil), mac...), nil
+}
+
+// ToBool decodes a value encoded with Bool.
+func ToBool(b []byte) (bool, error) {
+	if err := checkLen(b, 1); err != nil {
+		return false, err
+	}
+	return b[0] != 0, nil
+}
+
+// ToUint8 decodes a value encoded with Uint8.
+func ToUint8(b []byte) (uint8, error) {
+	if err := checkLen(b, 1); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// ToUint16 decodes a value encoded with Uint16.
+func ToUint16(b []byte) (uint16, error) {
+	if err := checkLen(b, 2); err != nil {
+		return 0, err
+	}
+	return binary.NativeEndian.Uint16(b), nil
+}
+
+// ToUint32 decodes a value encoded with Uint32.
+func ToUint32(b []byte) (uint32, error) {
+	if err := checkLen(b, 4); err != nil {
+		return 0, err
+	}
+	return binary.NativeEndian.Uint32(b), nil
+}
+
+// ToUint64 decodes a value encoded with Uint64.
+func ToUint64(b []byte) (uint64, error) {
+	if err := checkLen(b, 8); err != nil {
+		return 0, err
+	}
+	return binary.NativeEndian.Uint64(b), nil
+}
+
+// ToIPv4 decodes a value encoded with IPv4.
+func ToIPv4(b []byte) (net.IP, error) {
+	if err := checkLen(b, net.IPv4len); err != nil {
+		return nil, err
+	}
+	return net.IPv4(b[0], b[1], b[2], b[3]), nil
+}
+
+// ToIPv6 decodes a value encoded with IPv6.
+func ToIPv6(b []byte) (net.IP, error) {
+	if err := checkLen(b, net.IPv6len); err != nil {
+		return nil, err
+	}
+	return net.IP(append([]byte(nil), b...)), nil
+}
+
+// ToMAC decodes a value encoded with MAC.
+func ToMAC(b []byte) (net.HardwareAddr, error) {
+	if err := checkLen(b, 6); err != nil {
+		return nil, err
+	}
+	return net.HardwareAddr(append([]byte(nil), b...)), nil
+}
+
+func checkLen(b []byte, want int) error {
+	if len(b) != want {
+		return fmt.Errorf("invalid global data length %d, expected %d", len(b), want)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package globaldata
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
+	"github.com/bpfman/bpfman/clients/gobpfman/fakebpfman"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, v := range []bool{false, true} {
+		got, err := ToBool(Bool(v))
+		if err != nil || got != v {
+			t.Errorf("ToBool(Bool(%v)) = %v, %v", v, got, err)
+		}
+	}
+	for _, v := range []uint8{0, 1, math.MaxUint8} {
+		got, err := ToUint8(Uint8(v))
+		if err != nil || got != v {
+			t.Errorf("ToUint8(Uint8(%d)) = %d, %v", v, got, err)
+		}
+	}
+	for _, v := range []uint16{0, 0x0102, math.MaxUint16} {
+		got, err := ToUint16(Uint16(v))
+		if err != nil || got != v {
+			t.Errorf("ToUint16(Uint16(%d)) = %d, %v", v, got, err)
+		}
+	}
+	for _, v := range []uint32{0, 0x01020304, math.MaxUint32} {
+		got, err := ToUint32(Uint32(v))
+		if err != nil || got != v {
+			t.Errorf("ToUint32(Uint32(%d)) = %d, %v", v, got, err)
+		}
+	}
+	for _, v := range []uint64{0, 0x0102030405060708, math.MaxUint64} {
+		got, err := ToUint64(Uint64(v))
+		if err != nil || got != v {
+			t.Errorf("ToUint64(Uint64(%d)) = %d, %v", v, got, err)
+		}
+	}
+
+	for _, s := range []string{"10.0.0.1", "255.255.255.255"} {
+		ip := net.ParseIP(s)
+		b, err := IPv4(ip)
+		if err != nil {
+			t.Fatalf("IPv4(%s) failed: %v", s, err)
+		}
+		if !bytes.Equal(b, ip.To4()) {
+			t.Errorf("IPv4(%s) = %v, expected network byte order", s, b)
+		}
+		got, err := ToIPv4(b)
+		if err != nil || !got.Equal(ip) {
+			t.Errorf("ToIPv4(IPv4(%s)) = %s, %v", s, got, err)
+		}
+	}
+
+	for _, s := range []string{"fd00::1", "::", "::ffff:10.0.0.1", "10.0.0.1"} {
+		ip := net.ParseIP(s)
+		b, err := IPv6(ip)
+		if err != nil {
+			t.Fatalf("IPv6(%s) failed: %v", s, err)
+		}
+		if len(b) != net.IPv6len {
+			t.Errorf("IPv6(%s) returned %d bytes", s, len(b))
+		}
+		got, err := ToIPv6(b)
+		if err != nil || !got.Equal(ip) {
+			t.Errorf("ToIPv6(IPv6(%s)) = %s, %v", s, got, err)
+		}
+	}
+
+	mac, _ := net.ParseMAC("02:00:5e:10:00:01")
+	b, err := MAC(mac)
+	if err != nil {
+		t.Fatalf("MAC(%s) failed: %v", mac, err)
+	}
+	got, err := ToMAC(b)
+	if err != nil || !bytes.Equal(got, mac) {
+		t.Errorf("ToMAC(MAC(%s)) = %s, %v", mac, got, err)
+	}
+}
+
+// TestEncodersCopy checks that encoded values do not share storage with the
+// caller's address.
+func TestEncodersCopy(t *testing.T) {
+	ip := net.ParseIP("10.0.0.1")
+	v4, _ := IPv4(ip)
+	v6, _ := IPv6(ip)
+	ip[15] = 9
+	if !bytes.Equal(v4, []byte{10, 0, 0, 1}) {
+		t.Errorf("IPv4 result changed to %v with its input", v4)
+	}
+	if v6[15] != 1 {
+		t.Errorf("IPv6 result changed to %v with its input", v6)
+	}
+
+	mac, _ := net.ParseMAC("02:00:5e:10:00:01")
+	b, _ := MAC(mac)
+	mac[5] = 9
+	if b[5] != 1 {
+		t.Errorf("MAC result changed to %v with its input", b)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	if _, err := IPv4(net.ParseIP("fd00::1")); err == nil {
+		t.Errorf("IPv4 accepted an IPv6 address")
+	}
+	if _, err := IPv4(nil); err == nil {
+		t.Errorf("IPv4 accepted a nil address")
+	}
+	if _, err := IPv6(net.IP{1, 2, 3}); err == nil {
+		t.Errorf("IPv6 accepted a 3 byte address")
+	}
+	if _, err := MAC(net.HardwareAddr{1, 2, 3, 4, 5, 6, 7, 8}); err == nil {
+		t.Errorf("MAC accepted an 8 byte address")
+	}
+}
+
+func TestDecodeWrongLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func([]byte) error
+		want   int
+	}{
+		{"ToBool", func(b []byte) error { _, err := ToBool(b); return err }, 1},
+		{"ToUint8", func(b []byte) error { _, err := ToUint8(b); return err }, 1},
+		{"ToUint16", func(b []byte) error { _, err := ToUint16(b); return err }, 2},
+		{"ToUint32", func(b []byte) error { _, err := ToUint32(b); return err }, 4},
+		{"ToUint64", func(b []byte) error { _, err := ToUint64(b); return err }, 8},
+		{"ToIPv4", func(b []byte) error { _, err := ToIPv4(b); return err }, 4},
+		{"ToIPv6", func(b []byte) error { _, err := ToIPv6(b); return err }, 16},
+		{"ToMAC", func(b []byte) error { _, err := ToMAC(b); return err }, 6},
+	}
+	for _, tt := range tests {
+		for _, n := range []int{0, tt.want - 1, tt.want + 1} {
+			if err := tt.decode(make([]byte, n)); err == nil {
+				t.Errorf("%s accepted %d bytes, expected %d", tt.name, n, tt.want)
+			}
+		}
+		if err := tt.decode(make([]byte, tt.want)); err != nil {
+			t.Errorf("%s rejected %d bytes: %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	s := fakebpfman.New()
+	conn, err := s.Start()
+	if err != nil {
+		t.Fatalf("failed to start fake bpfman: %v", err)
+	}
+	defer s.Stop()
+	defer conn.Close()
+	c := gobpfman.NewBpfmanClient(conn)
+	ctx := context.Background()
+
+	req, err := builder.NewTracepointLoad().
+		FromFile("/tmp/tracepoint.o").
+		Name("tracepoint_kill_recorder").
+		Tracepoint("syscalls/sys_enter_kill").
+		GlobalData("GLOBAL_u32", Uint32(0x0a0b0c0d)).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	res, err := c.Load(ctx, req)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	data, err := Get(ctx, c, res.GetKernelInfo().GetId())
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	got, err := ToUint32(data["GLOBAL_u32"])
+	if err != nil || got != 0x0a0b0c0d {
+		t.Errorf("GLOBAL_u32 = %#x, %v", got, err)
+	}
+
+	if _, err := Get(ctx, c, 9999); err == nil {
+		t.Errorf("Get succeeded for an unknown program")
+	}
+
+	id := s.AddKernelProgram(&gobpfman.KernelProgramInfo{Name: "not_bpfman"})
+	if _, err := Get(ctx, c, id); err == nil {
+		t.Errorf("Get succeeded for a program not managed by bpfman")
+	}
+}
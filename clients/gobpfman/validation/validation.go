@@ -0,0 +1,290 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation checks bpfman LoadRequests and their attach parameters
+// on the client side, so malformed requests are rejected before they reach
+// bpfman. The bytecode source, program name, attach info, direction and
+// proceed-on checks are the ones bpfman itself makes. The priority range comes
+// from the bpfman-operator CRD schemas and is not enforced by bpfman, so
+// bpfman accepts some requests this package rejects.
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+const (
+	// MinPriority and MaxPriority bound the priority of XDP, TC and TCX
+	// programs, as the bpfman-operator CRD schemas do. bpfman itself does
+	// not enforce the range. Lower values run first.
+	MinPriority = 0
+	MaxPriority = 1000
+)
+
+// Kernel program types, as carried in LoadRequest.ProgramType. Uprobe and
+// TCX programs use the kprobe and TC types, fentry and fexit use tracing.
+const (
+	ProgramTypeKprobe     = 2
+	ProgramTypeTc         = 3
+	ProgramTypeTracepoint = 5
+	ProgramTypeXdp        = 6
+	ProgramTypeTracing    = 26
+)
+
+// XDP program return codes accepted in XDPAttachInfo.ProceedOn.
+const (
+	XdpAborted          = 0
+	XdpDrop             = 1
+	XdpPass             = 2
+	XdpTx               = 3
+	XdpRedirect         = 4
+	XdpDispatcherReturn = 31
+)
+
+// TC program return codes accepted in TCAttachInfo.ProceedOn.
+const (
+	TcUnspec           = -1
+	TcOk               = 0
+	TcReclassify       = 1
+	TcShot             = 2
+	TcPipe             = 3
+	TcStolen           = 4
+	TcQueued           = 5
+	TcRepeat           = 6
+	TcRedirect         = 7
+	TcTrap             = 8
+	TcDispatcherReturn = 30
+)
+
+var xdpProceedOn = map[string]int32{
+	"aborted":           XdpAborted,
+	"drop":              XdpDrop,
+	"pass":              XdpPass,
+	"tx":                XdpTx,
+	"redirect":          XdpRedirect,
+	"dispatcher_return": XdpDispatcherReturn,
+}
+
+var tcProceedOn = map[string]int32{
+	"unspec":            TcUnspec,
+	"ok":                TcOk,
+	"reclassify":        TcReclassify,
+	"shot":              TcShot,
+	"pipe":              TcPipe,
+	"stolen":            TcStolen,
+	"queued":            TcQueued,
+	"repeat":            TcRepeat,
+	"redirect":          TcRedirect,
+	"trap":              TcTrap,
+	"dispatcher_return": TcDispatcherReturn,
+}
+
+var directions = []string{"ingress", "egress"}
+
+// XdpProceedOn returns the return code for an XDP proceed-on name such as
+// "pass" or "dispatcher_return".
+func XdpProceedOn(name string) (int32, error) {
+	v, ok := xdpProceedOn[name]
+	if !ok {
+		return 0, fmt.Errorf("invalid xdp proceed-on %q, valid values are %s", name, names(xdpProceedOn))
+	}
+	return v, nil
+}
+
+// TcProceedOn returns the return code for a TC proceed-on name such as "pipe"
+// or "dispatcher_return".
+func TcProceedOn(name string) (int32, error) {
+	v, ok := tcProceedOn[name]
+	if !ok {
+		return 0, fmt.Errorf("invalid tc proceed-on %q, valid values are %s", name, names(tcProceedOn))
+	}
+	return v, nil
+}
+
+// ValidateXdpProceedOn checks that every code is a valid XDP proceed-on value.
+func ValidateXdpProceedOn(codes []int32) error {
+	return validateProceedOn("xdp", xdpProceedOn, codes)
+}
+
+// ValidateTcProceedOn checks that every code is a valid TC proceed-on value.
+func ValidateTcProceedOn(codes []int32) error {
+	return validateProceedOn("tc", tcProceedOn, codes)
+}
+
+// ValidatePriority checks that priority is within MinPriority and
+// MaxPriority.
+func ValidatePriority(priority int32) error {
+	if priority < MinPriority || priority > MaxPriority {
+		return fmt.Errorf("invalid priority %d, must be between %d and %d", priority, MinPriority, MaxPriority)
+	}
+	return nil
+}
+
+// ValidateDirection checks that direction is "ingress" or "egress".
+func ValidateDirection(direction string) error {
+	for _, d := range directions {
+		if direction == d {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid direction %q, valid values are %s", direction, strings.Join(directions, ", "))
+}
+
+// ValidateLoadRequest checks that req names a bytecode source and program,
+// that its program type matches its attach type, and that the attach
+// parameters are valid for that type.
+func ValidateLoadRequest(req *gobpfman.LoadRequest) error {
+	switch location := req.GetBytecode().GetLocation().(type) {
+	case *gobpfman.BytecodeLocation_Image:
+		if len(location.Image.GetUrl()) == 0 {
+			return fmt.Errorf("image url is required")
+		}
+	case *gobpfman.BytecodeLocation_File:
+		if len(location.File) == 0 {
+			return fmt.Errorf("bytecode file path is required")
+		}
+	default:
+		return fmt.Errorf("bytecode source is required")
+	}
+	if len(req.GetName()) == 0 {
+		return fmt.Errorf("program name is required")
+	}
+
+	switch info := req.GetAttach().GetInfo().(type) {
+	case *gobpfman.AttachInfo_XdpAttachInfo:
+		if err := checkProgramType(req, ProgramTypeXdp, "xdp"); err != nil {
+			return err
+		}
+		return validateXdp(info.XdpAttachInfo)
+	case *gobpfman.AttachInfo_TcAttachInfo:
+		if err := checkProgramType(req, ProgramTypeTc, "tc"); err != nil {
+			return err
+		}
+		return validateTc(info.TcAttachInfo)
+	case *gobpfman.AttachInfo_TcxAttachInfo:
+		if err := checkProgramType(req, ProgramTypeTc, "tcx"); err != nil {
+			return err
+		}
+		return validateTcx(info.TcxAttachInfo)
+	case *gobpfman.AttachInfo_TracepointAttachInfo:
+		if err := checkProgramType(req, ProgramTypeTracepoint, "tracepoint"); err != nil {
+			return err
+		}
+		if len(info.TracepointAttachInfo.GetTracepoint()) == 0 {
+			return fmt.Errorf("tracepoint is required")
+		}
+	case *gobpfman.AttachInfo_KprobeAttachInfo:
+		if err := checkProgramType(req, ProgramTypeKprobe, "kprobe"); err != nil {
+			return err
+		}
+		if len(info.KprobeAttachInfo.GetFnName()) == 0 {
+			return fmt.Errorf("function name is required")
+		}
+	case *gobpfman.AttachInfo_UprobeAttachInfo:
+		if err := checkProgramType(req, ProgramTypeKprobe, "uprobe"); err != nil {
+			return err
+		}
+		if len(info.UprobeAttachInfo.GetTarget()) == 0 {
+			return fmt.Errorf("target is required")
+		}
+	case *gobpfman.AttachInfo_FentryAttachInfo:
+		if err := checkProgramType(req, ProgramTypeTracing, "fentry"); err != nil {
+			return err
+		}
+		if len(info.FentryAttachInfo.GetFnName()) == 0 {
+			return fmt.Errorf("function name is required")
+		}
+	case *gobpfman.AttachInfo_FexitAttachInfo:
+		if err := checkProgramType(req, ProgramTypeTracing, "fexit"); err != nil {
+			return err
+		}
+		if len(info.FexitAttachInfo.GetFnName()) == 0 {
+			return fmt.Errorf("function name is required")
+		}
+	default:
+		return fmt.Errorf("attach info is required")
+	}
+	return nil
+}
+
+func validateXdp(info *gobpfman.XDPAttachInfo) error {
+	if len(info.GetIface()) == 0 {
+		return fmt.Errorf("interface is required")
+	}
+	if err := ValidatePriority(info.GetPriority()); err != nil {
+		return err
+	}
+	return ValidateXdpProceedOn(info.GetProceedOn())
+}
+
+func validateTc(info *gobpfman.TCAttachInfo) error {
+	if len(info.GetIface()) == 0 {
+		return fmt.Errorf("interface is required")
+	}
+	if err := ValidateDirection(info.GetDirection()); err != nil {
+		return err
+	}
+	if err := ValidatePriority(info.GetPriority()); err != nil {
+		return err
+	}
+	return ValidateTcProceedOn(info.GetProceedOn())
+}
+
+func validateTcx(info *gobpfman.TCXAttachInfo) error {
+	if len(info.GetIface()) == 0 {
+		return fmt.Errorf("interface is required")
+	}
+	if err := ValidateDirection(info.GetDirection()); err != nil {
+		return err
+	}
+	return ValidatePriority(info.GetPriority())
+}
+
+func checkProgramType(req *gobpfman.LoadRequest, want uint32, attach string) error {
+	if req.GetProgramType() != want {
+		return fmt.Errorf("program type %d does not match %s attach info, expected %d", req.GetProgramType(), attach, want)
+	}
+	return nil
+}
+
+func validateProceedOn(kind string, valid map[string]int32, codes []int32) error {
+	for _, c := range codes {
+		found := false
+		for _, v := range valid {
+			if c == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid %s proceed-on value %d", kind, c)
+		}
+	}
+	return nil
+}
+
+func names(m map[string]int32) string {
+	n := make([]string, 0, len(m))
+	for k := range m {
+		n = append(n, k)
+	}
+	sort.Slice(n, func(i, j int) bool { return m[n[i]] < m[n[j]] })
+	return strings.Join(n, ", ")
+}
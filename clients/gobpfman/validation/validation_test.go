@@ -0,0 +1,282 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+)
+
+func xdpAttach(info *gobpfman.XDPAttachInfo) *gobpfman.AttachInfo {
+	return &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_XdpAttachInfo{XdpAttachInfo: info}}
+}
+
+func tcAttach(info *gobpfman.TCAttachInfo) *gobpfman.AttachInfo {
+	return &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_TcAttachInfo{TcAttachInfo: info}}
+}
+
+func tcxAttach(info *gobpfman.TCXAttachInfo) *gobpfman.AttachInfo {
+	return &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_TcxAttachInfo{TcxAttachInfo: info}}
+}
+
+var (
+	validXdp = xdpAttach(&gobpfman.XDPAttachInfo{Iface: "eth0", Priority: 50})
+	validTc  = tcAttach(&gobpfman.TCAttachInfo{Iface: "eth0", Direction: "ingress", Priority: 50})
+	validTcx = tcxAttach(&gobpfman.TCXAttachInfo{Iface: "eth0", Direction: "egress", Priority: 50})
+
+	validTracepoint = &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_TracepointAttachInfo{
+		TracepointAttachInfo: &gobpfman.TracepointAttachInfo{Tracepoint: "syscalls/sys_enter_kill"},
+	}}
+	validKprobe = &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_KprobeAttachInfo{
+		KprobeAttachInfo: &gobpfman.KprobeAttachInfo{FnName: "try_to_wake_up"},
+	}}
+	validUprobe = &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_UprobeAttachInfo{
+		UprobeAttachInfo: &gobpfman.UprobeAttachInfo{Target: "libc"},
+	}}
+	validFentry = &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_FentryAttachInfo{
+		FentryAttachInfo: &gobpfman.FentryAttachInfo{FnName: "do_unlinkat"},
+	}}
+	validFexit = &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_FexitAttachInfo{
+		FexitAttachInfo: &gobpfman.FexitAttachInfo{FnName: "do_unlinkat"},
+	}}
+)
+
+func loadRequest(programType uint32, attach *gobpfman.AttachInfo) *gobpfman.LoadRequest {
+	return &gobpfman.LoadRequest{
+		Bytecode: &gobpfman.BytecodeLocation{
+			Location: &gobpfman.BytecodeLocation_File{File: "/tmp/prog.o"},
+		},
+		Name:        "prog",
+		ProgramType: programType,
+		Attach:      attach,
+	}
+}
+
+func TestValidateLoadRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *gobpfman.LoadRequest
+		// err is a substring of the expected error, or empty if the request
+		// is valid.
+		err string
+	}{
+		{name: "nil request", req: nil, err: "bytecode source is required"},
+		{
+			name: "no bytecode",
+			req:  &gobpfman.LoadRequest{Name: "prog", ProgramType: ProgramTypeXdp, Attach: validXdp},
+			err:  "bytecode source is required",
+		},
+		{
+			name: "empty image url",
+			req: &gobpfman.LoadRequest{
+				Bytecode: &gobpfman.BytecodeLocation{
+					Location: &gobpfman.BytecodeLocation_Image{Image: &gobpfman.BytecodeImage{}},
+				},
+				Name:        "prog",
+				ProgramType: ProgramTypeXdp,
+				Attach:      validXdp,
+			},
+			err: "image url is required",
+		},
+		{
+			name: "empty file path",
+			req: &gobpfman.LoadRequest{
+				Bytecode:    &gobpfman.BytecodeLocation{Location: &gobpfman.BytecodeLocation_File{}},
+				Name:        "prog",
+				ProgramType: ProgramTypeXdp,
+				Attach:      validXdp,
+			},
+			err: "bytecode file path is required",
+		},
+		{
+			name: "no name",
+			req: &gobpfman.LoadRequest{
+				Bytecode:    loadRequest(ProgramTypeXdp, validXdp).Bytecode,
+				ProgramType: ProgramTypeXdp,
+				Attach:      validXdp,
+			},
+			err: "program name is required",
+		},
+		{name: "no attach info", req: loadRequest(ProgramTypeXdp, nil), err: "attach info is required"},
+
+		{name: "xdp", req: loadRequest(ProgramTypeXdp, validXdp)},
+		{name: "tc", req: loadRequest(ProgramTypeTc, validTc)},
+		{name: "tcx", req: loadRequest(ProgramTypeTc, validTcx)},
+		{name: "tracepoint", req: loadRequest(ProgramTypeTracepoint, validTracepoint)},
+		{name: "kprobe", req: loadRequest(ProgramTypeKprobe, validKprobe)},
+		{name: "uprobe", req: loadRequest(ProgramTypeKprobe, validUprobe)},
+		{name: "fentry", req: loadRequest(ProgramTypeTracing, validFentry)},
+		{name: "fexit", req: loadRequest(ProgramTypeTracing, validFexit)},
+
+		{name: "xdp with tc type", req: loadRequest(ProgramTypeTc, validXdp), err: "does not match xdp"},
+		{name: "tc with xdp type", req: loadRequest(ProgramTypeXdp, validTc), err: "does not match tc"},
+		{name: "tcx with xdp type", req: loadRequest(ProgramTypeXdp, validTcx), err: "does not match tcx"},
+		{name: "tracepoint with kprobe type", req: loadRequest(ProgramTypeKprobe, validTracepoint), err: "does not match tracepoint"},
+		{name: "kprobe with tracepoint type", req: loadRequest(ProgramTypeTracepoint, validKprobe), err: "does not match kprobe"},
+		{name: "uprobe with tracing type", req: loadRequest(ProgramTypeTracing, validUprobe), err: "does not match uprobe"},
+		{name: "fentry with kprobe type", req: loadRequest(ProgramTypeKprobe, validFentry), err: "does not match fentry"},
+		{name: "fexit with kprobe type", req: loadRequest(ProgramTypeKprobe, validFexit), err: "does not match fexit"},
+
+		{
+			name: "xdp without interface",
+			req:  loadRequest(ProgramTypeXdp, xdpAttach(&gobpfman.XDPAttachInfo{})),
+			err:  "interface is required",
+		},
+		{
+			name: "tc with invalid direction",
+			req:  loadRequest(ProgramTypeTc, tcAttach(&gobpfman.TCAttachInfo{Iface: "eth0", Direction: "both"})),
+			err:  "invalid direction",
+		},
+		{
+			name: "tcx without direction",
+			req:  loadRequest(ProgramTypeTc, tcxAttach(&gobpfman.TCXAttachInfo{Iface: "eth0"})),
+			err:  "invalid direction",
+		},
+		{
+			name: "tracepoint without tracepoint",
+			req: loadRequest(ProgramTypeTracepoint, &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_TracepointAttachInfo{
+				TracepointAttachInfo: &gobpfman.TracepointAttachInfo{},
+			}}),
+			err: "tracepoint is required",
+		},
+		{
+			name: "uprobe without target",
+			req: loadRequest(ProgramTypeKprobe, &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_UprobeAttachInfo{
+				UprobeAttachInfo: &gobpfman.UprobeAttachInfo{Offset: 16},
+			}}),
+			err: "target is required",
+		},
+
+		{
+			name: "xdp priority -1",
+			req:  loadRequest(ProgramTypeXdp, xdpAttach(&gobpfman.XDPAttachInfo{Iface: "eth0", Priority: -1})),
+			err:  "invalid priority -1",
+		},
+		{
+			name: "xdp priority 0",
+			req:  loadRequest(ProgramTypeXdp, xdpAttach(&gobpfman.XDPAttachInfo{Iface: "eth0", Priority: 0})),
+		},
+		{
+			name: "tc priority 1000",
+			req:  loadRequest(ProgramTypeTc, tcAttach(&gobpfman.TCAttachInfo{Iface: "eth0", Direction: "ingress", Priority: 1000})),
+		},
+		{
+			name: "tc priority 1001",
+			req:  loadRequest(ProgramTypeTc, tcAttach(&gobpfman.TCAttachInfo{Iface: "eth0", Direction: "ingress", Priority: 1001})),
+			err:  "invalid priority 1001",
+		},
+		{
+			name: "tcx priority -1",
+			req:  loadRequest(ProgramTypeTc, tcxAttach(&gobpfman.TCXAttachInfo{Iface: "eth0", Direction: "ingress", Priority: -1})),
+			err:  "invalid priority -1",
+		},
+		{
+			name: "tcx priority 1001",
+			req:  loadRequest(ProgramTypeTc, tcxAttach(&gobpfman.TCXAttachInfo{Iface: "eth0", Direction: "ingress", Priority: 1001})),
+			err:  "invalid priority 1001",
+		},
+
+		{
+			name: "tc proceed-on unspec and dispatcher_return",
+			req: loadRequest(ProgramTypeTc, tcAttach(&gobpfman.TCAttachInfo{
+				Iface: "eth0", Direction: "ingress", ProceedOn: []int32{TcUnspec, TcDispatcherReturn},
+			})),
+		},
+		{
+			name: "tc proceed-on xdp dispatcher_return",
+			req: loadRequest(ProgramTypeTc, tcAttach(&gobpfman.TCAttachInfo{
+				Iface: "eth0", Direction: "ingress", ProceedOn: []int32{XdpDispatcherReturn},
+			})),
+			err: "invalid tc proceed-on value 31",
+		},
+		{
+			name: "xdp proceed-on pass and dispatcher_return",
+			req: loadRequest(ProgramTypeXdp, xdpAttach(&gobpfman.XDPAttachInfo{
+				Iface: "eth0", ProceedOn: []int32{XdpPass, XdpDispatcherReturn},
+			})),
+		},
+		{
+			name: "xdp proceed-on tc dispatcher_return",
+			req: loadRequest(ProgramTypeXdp, xdpAttach(&gobpfman.XDPAttachInfo{
+				Iface: "eth0", ProceedOn: []int32{TcDispatcherReturn},
+			})),
+			err: "invalid xdp proceed-on value 30",
+		},
+		{
+			name: "xdp proceed-on unspec",
+			req: loadRequest(ProgramTypeXdp, xdpAttach(&gobpfman.XDPAttachInfo{
+				Iface: "eth0", ProceedOn: []int32{TcUnspec},
+			})),
+			err: "invalid xdp proceed-on value -1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLoadRequest(tt.req)
+			switch {
+			case len(tt.err) == 0 && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case len(tt.err) != 0 && err == nil:
+				t.Errorf("expected error containing %q", tt.err)
+			case len(tt.err) != 0 && !strings.Contains(err.Error(), tt.err):
+				t.Errorf("error %q does not contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestProceedOnNames(t *testing.T) {
+	xdp := map[string]int32{"aborted": XdpAborted, "pass": XdpPass, "dispatcher_return": XdpDispatcherReturn}
+	for name, want := range xdp {
+		got, err := XdpProceedOn(name)
+		if err != nil || got != want {
+			t.Errorf("XdpProceedOn(%q) = %d, %v, expected %d", name, got, err, want)
+		}
+	}
+	tc := map[string]int32{"unspec": TcUnspec, "pipe": TcPipe, "dispatcher_return": TcDispatcherReturn}
+	for name, want := range tc {
+		got, err := TcProceedOn(name)
+		if err != nil || got != want {
+			t.Errorf("TcProceedOn(%q) = %d, %v, expected %d", name, got, err, want)
+		}
+	}
+
+	// Names valid for one hook are not valid for the other.
+	for _, name := range []string{"", "PASS", "pipe", "shot"} {
+		_, err := XdpProceedOn(name)
+		if err == nil {
+			t.Errorf("XdpProceedOn(%q) succeeded", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), "aborted, drop, pass, tx, redirect, dispatcher_return") {
+			t.Errorf("XdpProceedOn(%q) error %q does not list the valid names", name, err)
+		}
+	}
+	for _, name := range []string{"", "PIPE", "pass", "drop"} {
+		_, err := TcProceedOn(name)
+		if err == nil {
+			t.Errorf("TcProceedOn(%q) succeeded", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), "unspec, ok, reclassify") {
+			t.Errorf("TcProceedOn(%q) error %q does not list the valid names", name, err)
+		}
+	}
+}
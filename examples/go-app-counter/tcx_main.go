@@ -9,7 +9,7 @@ import (
 	"log"
 	"time"
 
-	bpfmanHelpers "github.com/bpfman/bpfman-operator/pkg/helpers"
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
 	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 	configMgmt "github.com/bpfman/bpfman/examples/pkg/config-mgmt"
 	"github.com/cilium/ebpf"
@@ -26,13 +26,13 @@ const (
 
 func processTcx(cancelCtx context.Context, paramData *configMgmt.ParameterData) {
 	var action string
-	var direction bpfmanHelpers.TcProgramDirection
+	var direction builder.Direction
 	if paramData.Direction == configMgmt.TcDirectionIngress {
 		action = "received"
-		direction = bpfmanHelpers.Ingress
+		direction = builder.Ingress
 	} else {
 		action = "sent"
-		direction = bpfmanHelpers.Egress
+		direction = builder.Egress
 	}
 
 	var mapPath string
@@ -48,44 +48,21 @@ func processTcx(cancelCtx context.Context, paramData *configMgmt.ParameterData)
 		// Set up a connection to the server.
 		// If the bytecode src is a Program ID, skip the loading and unloading of the bytecode.
 		if paramData.BytecodeSrc != configMgmt.SrcProgId {
-			var loadRequest *gobpfman.LoadRequest
-			if paramData.MapOwnerId != 0 {
-				mapOwnerId := uint32(paramData.MapOwnerId)
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "tcx_stats",
-					ProgramType: *bpfmanHelpers.Tc.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_TcxAttachInfo{
-							TcxAttachInfo: &gobpfman.TCXAttachInfo{
-								Priority:  int32(paramData.Priority),
-								Iface:     paramData.Iface,
-								Direction: direction.String(),
-							},
-						},
-					},
-					MapOwnerId: &mapOwnerId,
-				}
-			} else {
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "tcx_stats",
-					ProgramType: *bpfmanHelpers.Tc.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_TcxAttachInfo{
-							TcxAttachInfo: &gobpfman.TCXAttachInfo{
-								Priority:  int32(paramData.Priority),
-								Iface:     paramData.Iface,
-								Direction: direction.String(),
-							},
-						},
-					},
-				}
+			loadRequest, err := builder.NewTcxLoad().
+				FromBytecode(paramData.BytecodeSource).
+				Name("tcx_stats").
+				Iface(paramData.Iface).
+				Direction(direction).
+				Priority(int32(paramData.Priority)).
+				MapOwner(uint32(paramData.MapOwnerId)).
+				Build()
+			if err != nil {
+				log.Print(err)
+				return
 			}
 
 			// 1. Load Program using bpfman
 			var res *gobpfman.LoadResponse
-			var err error
 			res, err = loadBpfProgram(loadRequest)
 			if err != nil {
 				log.Print(err)
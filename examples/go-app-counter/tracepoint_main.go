@@ -11,7 +11,7 @@ import (
 	"syscall"
 	"time"
 
-	bpfmanHelpers "github.com/bpfman/bpfman-operator/pkg/helpers"
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
 	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 	configMgmt "github.com/bpfman/bpfman/examples/pkg/config-mgmt"
 	"github.com/cilium/ebpf"
@@ -38,40 +38,19 @@ func processTracepoint(cancelCtx context.Context, paramData *configMgmt.Paramete
 	} else { // if not on k8s, find the map path from the system
 		// If the bytecode src is a Program ID, skip the loading and unloading of the bytecode.
 		if paramData.BytecodeSrc != configMgmt.SrcProgId {
-			var loadRequest *gobpfman.LoadRequest
-			if paramData.MapOwnerId != 0 {
-				mapOwnerId := uint32(paramData.MapOwnerId)
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "tracepoint_kill_recorder",
-					ProgramType: *bpfmanHelpers.Tracepoint.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_TracepointAttachInfo{
-							TracepointAttachInfo: &gobpfman.TracepointAttachInfo{
-								Tracepoint: "syscalls/sys_enter_kill",
-							},
-						},
-					},
-					MapOwnerId: &mapOwnerId,
-				}
-			} else {
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "tracepoint_kill_recorder",
-					ProgramType: *bpfmanHelpers.Tracepoint.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_TracepointAttachInfo{
-							TracepointAttachInfo: &gobpfman.TracepointAttachInfo{
-								Tracepoint: "syscalls/sys_enter_kill",
-							},
-						},
-					},
-				}
+			loadRequest, err := builder.NewTracepointLoad().
+				FromBytecode(paramData.BytecodeSource).
+				Name("tracepoint_kill_recorder").
+				Tracepoint("syscalls/sys_enter_kill").
+				MapOwner(uint32(paramData.MapOwnerId)).
+				Build()
+			if err != nil {
+				log.Print(err)
+				return
 			}
 
 			// 1. Load Program using bpfman
 			var res *gobpfman.LoadResponse
-			var err error
 			res, err = loadBpfProgram(loadRequest)
 			if err != nil {
 				log.Print(err)
@@ -9,7 +9,7 @@ import (
 	"log"
 	"time"
 
-	bpfmanHelpers "github.com/bpfman/bpfman-operator/pkg/helpers"
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
 	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 	configMgmt "github.com/bpfman/bpfman/examples/pkg/config-mgmt"
 	"github.com/cilium/ebpf"
@@ -40,42 +40,20 @@ func processXdp(cancelCtx context.Context, paramData *configMgmt.ParameterData)
 	} else {
 		// If the bytecode src is a Program ID, skip the loading and unloading of the bytecode.
 		if paramData.BytecodeSrc != configMgmt.SrcProgId {
-			var loadRequest *gobpfman.LoadRequest
-			if paramData.MapOwnerId != 0 {
-				mapOwnerId := uint32(paramData.MapOwnerId)
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "xdp_stats",
-					ProgramType: *bpfmanHelpers.Xdp.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_XdpAttachInfo{
-							XdpAttachInfo: &gobpfman.XDPAttachInfo{
-								Priority: int32(paramData.Priority),
-								Iface:    paramData.Iface,
-							},
-						},
-					},
-					MapOwnerId: &mapOwnerId,
-				}
-			} else {
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "xdp_stats",
-					ProgramType: *bpfmanHelpers.Xdp.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_XdpAttachInfo{
-							XdpAttachInfo: &gobpfman.XDPAttachInfo{
-								Priority: int32(paramData.Priority),
-								Iface:    paramData.Iface,
-							},
-						},
-					},
-				}
+			loadRequest, err := builder.NewXdpLoad().
+				FromBytecode(paramData.BytecodeSource).
+				Name("xdp_stats").
+				Iface(paramData.Iface).
+				Priority(int32(paramData.Priority)).
+				MapOwner(uint32(paramData.MapOwnerId)).
+				Build()
+			if err != nil {
+				log.Print(err)
+				return
 			}
 
 			// 1. Load Program using bpfman
 			var res *gobpfman.LoadResponse
-			var err error
 			res, err = loadBpfProgram(loadRequest)
 			if err != nil {
 				log.Print(err)
@@ -12,7 +12,7 @@ import (
 	"syscall"
 	"time"
 
-	bpfmanHelpers "github.com/bpfman/bpfman-operator/pkg/helpers"
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
 	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 	configMgmt "github.com/bpfman/bpfman/examples/pkg/config-mgmt"
 	"github.com/cilium/ebpf"
@@ -67,35 +67,16 @@ func main() {
 
 		// If the bytecode src is a Program ID, skip the loading and unloading of the bytecode.
 		if paramData.BytecodeSrc != configMgmt.SrcProgId {
-			var loadRequest *gobpfman.LoadRequest
-			if paramData.MapOwnerId != 0 {
-				mapOwnerId := uint32(paramData.MapOwnerId)
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "kprobe_counter",
-					ProgramType: *bpfmanHelpers.Kprobe.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_KprobeAttachInfo{
-							KprobeAttachInfo: &gobpfman.KprobeAttachInfo{
-								FnName: "try_to_wake_up",
-							},
-						},
-					},
-					MapOwnerId: &mapOwnerId,
-				}
-			} else {
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "kprobe_counter",
-					ProgramType: *bpfmanHelpers.Kprobe.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_KprobeAttachInfo{
-							KprobeAttachInfo: &gobpfman.KprobeAttachInfo{
-								FnName: "try_to_wake_up",
-							},
-						},
-					},
-				}
+			loadRequest, err := builder.NewKprobeLoad().
+				FromBytecode(paramData.BytecodeSource).
+				Name("kprobe_counter").
+				FnName("try_to_wake_up").
+				MapOwner(uint32(paramData.MapOwnerId)).
+				Build()
+			if err != nil {
+				conn.Close()
+				log.Print(err)
+				return
 			}
 
 			// 1. Load Program using bpfman
@@ -12,7 +12,7 @@ import (
 	"syscall"
 	"time"
 
-	bpfmanHelpers "github.com/bpfman/bpfman-operator/pkg/helpers"
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
 	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 	configMgmt "github.com/bpfman/bpfman/examples/pkg/config-mgmt"
 	"github.com/cilium/ebpf"
@@ -36,6 +36,19 @@ const (
 	TC_ACT_OK = 0
 )
 
+// buildLoadRequest returns the request used to load the TC counter through
+// bpfman.
+func buildLoadRequest(paramData configMgmt.ParameterData, direction builder.Direction) (*gobpfman.LoadRequest, error) {
+	return builder.NewTcLoad().
+		FromBytecode(paramData.BytecodeSource).
+		Name("stats").
+		Iface(paramData.Iface).
+		Direction(direction).
+		Priority(int32(paramData.Priority)).
+		MapOwner(uint32(paramData.MapOwnerId)).
+		Build()
+}
+
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -no-strip -cflags "-O2 -g -Wall" -target amd64,arm64,ppc64le,s390x bpf ./bpf/tc_counter.c -- -I.:/usr/include/bpf:/usr/include/linux
 func main() {
 	stop := make(chan os.Signal, 1)
@@ -49,13 +62,13 @@ func main() {
 	}
 
 	var action string
-	var direction bpfmanHelpers.TcProgramDirection
+	var direction builder.Direction
 	if paramData.Direction == configMgmt.TcDirectionIngress {
 		action = "received"
-		direction = bpfmanHelpers.Ingress
+		direction = builder.Ingress
 	} else {
 		action = "sent"
-		direction = bpfmanHelpers.Egress
+		direction = builder.Egress
 	}
 
 	var mapPath string
@@ -79,39 +92,11 @@ func main() {
 
 		// If the bytecode src is a Program ID, skip the loading and unloading of the bytecode.
 		if paramData.BytecodeSrc != configMgmt.SrcProgId {
-			var loadRequest *gobpfman.LoadRequest
-			if paramData.MapOwnerId != 0 {
-				mapOwnerId := uint32(paramData.MapOwnerId)
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "stats",
-					ProgramType: *bpfmanHelpers.Tc.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_TcAttachInfo{
-							TcAttachInfo: &gobpfman.TCAttachInfo{
-								Priority:  int32(paramData.Priority),
-								Iface:     paramData.Iface,
-								Direction: direction.String(),
-							},
-						},
-					},
-					MapOwnerId: &mapOwnerId,
-				}
-			} else {
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "stats",
-					ProgramType: *bpfmanHelpers.Xdp.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_TcAttachInfo{
-							TcAttachInfo: &gobpfman.TCAttachInfo{
-								Priority:  int32(paramData.Priority),
-								Iface:     paramData.Iface,
-								Direction: direction.String(),
-							},
-						},
-					},
-				}
+			loadRequest, err := buildLoadRequest(paramData, direction)
+			if err != nil {
+				conn.Close()
+				log.Print(err)
+				return
 			}
 
 			// 1. Load Program using bpfman
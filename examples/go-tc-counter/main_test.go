@@ -0,0 +1,86 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"testing"
+
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
+	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
+	configMgmt "github.com/bpfman/bpfman/examples/pkg/config-mgmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestBuildLoadRequest pins the request the example sends to bpfman, which
+// must carry the TC program type and TC attach info.
+func TestBuildLoadRequest(t *testing.T) {
+	image := &gobpfman.BytecodeLocation{
+		Location: &gobpfman.BytecodeLocation_Image{Image: &gobpfman.BytecodeImage{
+			Url:             "quay.io/bpfman-bytecode/go-tc-counter:latest",
+			ImagePullPolicy: int32(builder.PullIfNotPresent),
+		}},
+	}
+	mapOwner := uint32(7)
+	tests := []struct {
+		name      string
+		paramData configMgmt.ParameterData
+		direction builder.Direction
+		want      *gobpfman.LoadRequest
+	}{
+		{
+			name: "ingress",
+			paramData: configMgmt.ParameterData{
+				Iface:          "eth0",
+				Priority:       50,
+				BytecodeSource: image,
+				BytecodeSrc:    configMgmt.SrcImage,
+			},
+			direction: builder.Ingress,
+			want: &gobpfman.LoadRequest{
+				Bytecode:    image,
+				Name:        "stats",
+				ProgramType: 3,
+				Attach: &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_TcAttachInfo{TcAttachInfo: &gobpfman.TCAttachInfo{
+					Priority:  50,
+					Iface:     "eth0",
+					Direction: "ingress",
+				}}},
+			},
+		},
+		{
+			name: "egress with map owner",
+			paramData: configMgmt.ParameterData{
+				Iface:          "eth0",
+				Priority:       50,
+				MapOwnerId:     7,
+				BytecodeSource: image,
+				BytecodeSrc:    configMgmt.SrcImage,
+			},
+			direction: builder.Egress,
+			want: &gobpfman.LoadRequest{
+				Bytecode:    image,
+				Name:        "stats",
+				ProgramType: 3,
+				Attach: &gobpfman.AttachInfo{Info: &gobpfman.AttachInfo_TcAttachInfo{TcAttachInfo: &gobpfman.TCAttachInfo{
+					Priority:  50,
+					Iface:     "eth0",
+					Direction: "egress",
+				}}},
+				MapOwnerId: &mapOwner,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := buildLoadRequest(tt.paramData, tt.direction)
+			if err != nil {
+				t.Fatalf("buildLoadRequest failed: %v", err)
+			}
+			if !proto.Equal(req, tt.want) {
+				t.Errorf("request is %v, expected %v", req, tt.want)
+			}
+		})
+	}
+}
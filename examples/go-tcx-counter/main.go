@@ -12,7 +12,7 @@ import (
 	"syscall"
 	"time"
 
-	bpfmanHelpers "github.com/bpfman/bpfman-operator/pkg/helpers"
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
 	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 	configMgmt "github.com/bpfman/bpfman/examples/pkg/config-mgmt"
 	"github.com/cilium/ebpf"
@@ -32,6 +32,19 @@ const (
 	MapsMountPoint = "/run/tcx/maps"
 )
 
+// buildLoadRequest returns the request used to load the TCX counter through
+// bpfman.
+func buildLoadRequest(paramData configMgmt.ParameterData, direction builder.Direction) (*gobpfman.LoadRequest, error) {
+	return builder.NewTcxLoad().
+		FromBytecode(paramData.BytecodeSource).
+		Name("tcx_stats").
+		Iface(paramData.Iface).
+		Direction(direction).
+		Priority(int32(paramData.Priority)).
+		MapOwner(uint32(paramData.MapOwnerId)).
+		Build()
+}
+
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -no-strip -cflags "-O2 -g -Wall" -target amd64,arm64,ppc64le,s390x bpf ./bpf/tcx_counter.c -- -I.:/usr/include/bpf:/usr/include/linux
 func main() {
 	stop := make(chan os.Signal, 1)
@@ -45,13 +58,13 @@ func main() {
 	}
 
 	var action string
-	var direction bpfmanHelpers.TcProgramDirection
+	var direction builder.Direction
 	if paramData.Direction == configMgmt.TcDirectionIngress {
 		action = "received"
-		direction = bpfmanHelpers.Ingress
+		direction = builder.Ingress
 	} else {
 		action = "sent"
-		direction = bpfmanHelpers.Egress
+		direction = builder.Egress
 	}
 
 	var mapPath string
@@ -75,39 +88,11 @@ func main() {
 
 		// If the bytecode src is a Program ID, skip the loading and unloading of the bytecode.
 		if paramData.BytecodeSrc != configMgmt.SrcProgId {
-			var loadRequest *gobpfman.LoadRequest
-			if paramData.MapOwnerId != 0 {
-				mapOwnerId := uint32(paramData.MapOwnerId)
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "tcx_stats",
-					ProgramType: *bpfmanHelpers.Tc.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_TcxAttachInfo{
-							TcxAttachInfo: &gobpfman.TCXAttachInfo{
-								Priority:  int32(paramData.Priority),
-								Iface:     paramData.Iface,
-								Direction: direction.String(),
-							},
-						},
-					},
-					MapOwnerId: &mapOwnerId,
-				}
-			} else {
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "tcx_stats",
-					ProgramType: *bpfmanHelpers.Xdp.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_TcxAttachInfo{
-							TcxAttachInfo: &gobpfman.TCXAttachInfo{
-								Priority:  int32(paramData.Priority),
-								Iface:     paramData.Iface,
-								Direction: direction.String(),
-							},
-						},
-					},
-				}
+			loadRequest, err := buildLoadRequest(paramData, direction)
+			if err != nil {
+				conn.Close()
+				log.Print(err)
+				return
 			}
 
 			// 1. Load Program using bpfman
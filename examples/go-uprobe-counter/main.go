@@ -12,7 +12,7 @@ import (
 	"syscall"
 	"time"
 
-	bpfmanHelpers "github.com/bpfman/bpfman-operator/pkg/helpers"
+	"github.com/bpfman/bpfman/clients/gobpfman/builder"
 	gobpfman "github.com/bpfman/bpfman/clients/gobpfman/v1"
 	configMgmt "github.com/bpfman/bpfman/examples/pkg/config-mgmt"
 	"github.com/cilium/ebpf"
@@ -69,37 +69,17 @@ func main() {
 
 		// If the bytecode src is a Program ID, skip the loading and unloading of the bytecode.
 		if paramData.BytecodeSrc != configMgmt.SrcProgId {
-			var loadRequest *gobpfman.LoadRequest
-			if paramData.MapOwnerId != 0 {
-				mapOwnerId := uint32(paramData.MapOwnerId)
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "uprobe_counter",
-					ProgramType: *bpfmanHelpers.Kprobe.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_UprobeAttachInfo{
-							UprobeAttachInfo: &gobpfman.UprobeAttachInfo{
-								FnName: &fnName,
-								Target: "libc",
-							},
-						},
-					},
-					MapOwnerId: &mapOwnerId,
-				}
-			} else {
-				loadRequest = &gobpfman.LoadRequest{
-					Bytecode:    paramData.BytecodeSource,
-					Name:        "uprobe_counter",
-					ProgramType: *bpfmanHelpers.Kprobe.Uint32(),
-					Attach: &gobpfman.AttachInfo{
-						Info: &gobpfman.AttachInfo_UprobeAttachInfo{
-							UprobeAttachInfo: &gobpfman.UprobeAttachInfo{
-								FnName: &fnName,
-								Target: "libc",
-							},
-						},
-					},
-				}
+			loadRequest, err := builder.NewUprobeLoad().
+				FromBytecode(paramData.BytecodeSource).
+				Name("uprobe_counter").
+				Target("libc").
+				FnName(fnName).
+				MapOwner(uint32(paramData.MapOwnerId)).
+				Build()
+			if err != nil {
+				conn.Close()
+				log.Print(err)
+				return
 			}
 
 			// 1. Load Program using bpfman